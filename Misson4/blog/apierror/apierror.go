@@ -0,0 +1,127 @@
+// Package apierror 定义 API 返回给客户端的错误：HTTP 状态码、稳定的错误码、
+// 可读的错误信息和字段级别的校验详情。错误信息可能调整，客户端应根据错误码判断错误类型。
+package apierror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code 错误码
+type Code string
+
+// 通用错误码
+const (
+	CodeBadRequest       Code = "bad_request"            // 请求格式错误，如 JSON 无法解析、路径参数不是数字
+	CodeValidation       Code = "validation_failed"      // 字段校验失败，details 中列出每个字段的错误
+	CodeUnauthorized     Code = "unauthorized"           // 没有携带访问令牌
+	CodeForbidden        Code = "forbidden"              // 没有权限
+	CodeNotFound         Code = "not_found"              // 资源或路由不存在
+	CodeMethodNotAllowed Code = "method_not_allowed"     // 路由存在但不支持该请求方法
+	CodeConflict         Code = "conflict"               // 与现有数据冲突
+	CodePayloadTooLarge  Code = "payload_too_large"      // 上传的文件过大
+	CodeUnsupportedMedia Code = "unsupported_media_type" // 不支持的文件类型
+	CodeRateLimited      Code = "rate_limited"           // 请求过于频繁，响应头带有 Retry-After
+	CodeInternal         Code = "internal_error"         // 服务端错误，具体原因只记录在日志中
+)
+
+// 需要客户端区别处理的业务错误码
+const (
+	CodeInvalidToken        Code = "invalid_token"          // 访问令牌无效、过期或已注销，应刷新令牌
+	CodeInvalidRefreshToken Code = "invalid_refresh_token"  // 刷新令牌无效或已被使用，需要重新登录
+	CodeInvalidCredentials  Code = "invalid_credentials"    // 用户名或密码错误
+	CodeAccountLocked       Code = "account_locked"         // 登录失败次数过多，暂时锁定
+	CodeWrongPassword       Code = "wrong_password"         // 修改密码、注销账号时当前密码错误
+	CodeUsernameTaken       Code = "username_taken"         // 用户名已被注册
+	CodeEmailTaken          Code = "email_taken"            // 邮箱已被注册
+	CodeEmailVerified       Code = "email_already_verified" // 邮箱已经验证过
+	CodeInvalidLink         Code = "invalid_link"           // 邮件中的链接无效或已过期
+)
+
+// Error API 错误。Err 为内部原因，只写入日志，不返回给客户端
+type Error struct {
+	Status  int          `json:"-"`
+	Code    Code         `json:"code"`
+	Message string       `json:"message"`
+	Details []FieldError `json:"details,omitempty"`
+	Err     error        `json:"-"`
+}
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	// Field 请求中的字段名（JSON 字段或查询参数），嵌套字段用 . 连接
+	Field string `json:"field"`
+	// Rule 未通过的校验规则，如 required、max、oneof
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New 创建 API 错误
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// BadRequest 400 请求格式错误
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// Invalid 400 单个字段校验失败
+func Invalid(field, rule, message string) *Error {
+	return &Error{
+		Status:  http.StatusBadRequest,
+		Code:    CodeValidation,
+		Message: message,
+		Details: []FieldError{{Field: field, Rule: rule, Message: message}},
+	}
+}
+
+// Unauthorized 401 未登录
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// InvalidToken 401 访问令牌无效、过期或已注销，客户端据此刷新令牌
+func InvalidToken(message string) *Error {
+	return New(http.StatusUnauthorized, CodeInvalidToken, message)
+}
+
+// Forbidden 403 没有权限
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+// NotFound 404 资源不存在
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// Conflict 409 与现有数据冲突
+func Conflict(code Code, message string) *Error {
+	return New(http.StatusConflict, code, message)
+}
+
+// Internal 500 服务端错误，message 返回给客户端，err 只写入日志
+func Internal(message string, err error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: message, Err: err}
+}
+
+// From 把任意错误转换为 API 错误，不是 *Error 的错误按 500 处理
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return Internal("Internal server error", err)
+}
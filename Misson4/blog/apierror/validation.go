@@ -0,0 +1,126 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// RegisterFieldNames 让校验错误中的字段名使用 JSON 字段名或查询参数名，需在启动时调用一次
+func RegisterFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, tag := range []string{"json", "form", "uri"} {
+			if name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]; name != "" && name != "-" {
+				return name
+			}
+		}
+		return f.Name
+	})
+}
+
+// FromBinding 把 ShouldBindJSON、ShouldBindQuery 等返回的错误转换为 API 错误：
+// 字段校验失败时在 Details 中列出每个字段，JSON 格式或类型错误时指出出错的位置
+func FromBinding(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var numErr *strconv.NumError
+	var timeErr *time.ParseError
+
+	switch {
+	case errors.As(err, &validationErrs):
+		details := make([]FieldError, len(validationErrs))
+		for i, fe := range validationErrs {
+			details[i] = FieldError{Field: fe.Field(), Rule: fe.Tag(), Message: fieldMessage(fe)}
+		}
+		return &Error{
+			Status:  http.StatusBadRequest,
+			Code:    CodeValidation,
+			Message: "Request validation failed",
+			Details: details,
+			Err:     err,
+		}
+	case errors.Is(err, io.EOF):
+		return &Error{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: "Request body is required", Err: err}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return &Error{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: "Request body is not valid JSON", Err: err}
+	case errors.As(err, &typeErr):
+		message := fmt.Sprintf("%s must be %s", typeErr.Field, jsonTypeName(typeErr.Type))
+		apiErr := Invalid(typeErr.Field, "type", message)
+		apiErr.Err = err
+		return apiErr
+	case errors.As(err, &timeErr):
+		return &Error{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: fmt.Sprintf("Invalid time %q, use RFC3339", timeErr.Value), Err: err}
+	case errors.As(err, &numErr):
+		return &Error{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: fmt.Sprintf("Invalid number %q", numErr.Num), Err: err}
+	default:
+		return &Error{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: "Invalid request", Err: err}
+	}
+}
+
+// fieldMessage 校验规则对应的错误信息
+func fieldMessage(fe validator.FieldError) string {
+	field, param := fe.Field(), fe.Param()
+	unit := ""
+	switch fe.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return field + " is required"
+	case "email":
+		return field + " must be a valid email address"
+	case "min":
+		return fmt.Sprintf("%s must be at least %s%s", field, param, unit)
+	case "max":
+		return fmt.Sprintf("%s must be at most %s%s", field, param, unit)
+	case "len":
+		return fmt.Sprintf("%s must be exactly %s%s", field, param, unit)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.Join(strings.Fields(param), ", "))
+	default:
+		return fmt.Sprintf("%s does not satisfy %s", field, fe.Tag())
+	}
+}
+
+// jsonTypeName Go 类型对应的 JSON 类型描述
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		if t.String() == "time.Time" {
+			return "an RFC3339 time"
+		}
+		return "an object"
+	case reflect.Ptr:
+		return jsonTypeName(t.Elem())
+	}
+	return "a " + t.String()
+}
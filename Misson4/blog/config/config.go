@@ -0,0 +1,781 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config 博客服务的全部运行配置
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	JWT       JWTConfig       `yaml:"jwt" toml:"jwt"`
+	Storage   StorageConfig   `yaml:"storage" toml:"storage"`
+	Mail      MailConfig      `yaml:"mail" toml:"mail"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+}
+
+// ServerConfig HTTP 服务配置
+type ServerConfig struct {
+	Addr string `yaml:"addr" toml:"addr"`
+	// TrustedProxies 可信的反向代理地址或网段，只有来自这些地址的请求才使用
+	// X-Forwarded-For 中的客户端 IP；为空时直接使用连接的对端地址
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+// DatabaseConfig 数据库连接与连接池配置
+type DatabaseConfig struct {
+	// Driver 数据库驱动：mysql、sqlite 或 postgres
+	Driver          string   `yaml:"driver" toml:"driver"`
+	DSN             string   `yaml:"dsn" toml:"dsn"`
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	// AutoMigrate 为 true 时服务启动前自动执行未执行的迁移
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
+}
+
+// JWTConfig JWT 签名配置。签名密钥可以用 Secret 配置单个 HS256 密钥，
+// 也可以在 Keys 中配置多个密钥以便轮换：新令牌用 SigningKey 签发，其余密钥只用于校验尚未过期的旧令牌
+type JWTConfig struct {
+	// Secret 单个 HS256 密钥，等同于 Keys 中 id 为 "default" 的密钥
+	Secret string         `yaml:"secret" toml:"secret"`
+	Keys   []JWTKeyConfig `yaml:"keys" toml:"keys"`
+	// SigningKey 签发新令牌使用的密钥 id，为空时使用 Keys 中的第一个（没有 Keys 时为 Secret）
+	SigningKey string `yaml:"signing_key" toml:"signing_key"`
+	// Issuer、Audience 写入令牌的 iss 和 aud，校验时必须一致
+	Issuer   string `yaml:"issuer" toml:"issuer"`
+	Audience string `yaml:"audience" toml:"audience"`
+	// Expiry 访问令牌有效期，应尽量短，过期后用刷新令牌换取新令牌
+	Expiry Duration `yaml:"expiry" toml:"expiry"`
+	// RefreshExpiry 刷新令牌有效期
+	RefreshExpiry Duration `yaml:"refresh_expiry" toml:"refresh_expiry"`
+}
+
+// JWTKeyConfig 一个 JWT 签名密钥，id 写入令牌头部的 kid。
+// HS256 使用 Secret；RS256 和 EdDSA 使用 PEM 格式的密钥文件，只配置公钥时该密钥只能用于校验
+type JWTKeyConfig struct {
+	ID             string `yaml:"id" toml:"id"`
+	Algorithm      string `yaml:"algorithm" toml:"algorithm"`
+	Secret         string `yaml:"secret" toml:"secret"`
+	PrivateKeyFile string `yaml:"private_key_file" toml:"private_key_file"`
+	PublicKeyFile  string `yaml:"public_key_file" toml:"public_key_file"`
+}
+
+// StorageConfig 上传文件的存储配置
+type StorageConfig struct {
+	// Driver 存储后端：local 或 s3
+	Driver string `yaml:"driver" toml:"driver"`
+	// MaxUploadSize 单个上传文件的大小上限（字节）
+	MaxUploadSize int64              `yaml:"max_upload_size" toml:"max_upload_size"`
+	Local         LocalStorageConfig `yaml:"local" toml:"local"`
+	S3            S3StorageConfig    `yaml:"s3" toml:"s3"`
+}
+
+// LocalStorageConfig 本地磁盘存储配置
+type LocalStorageConfig struct {
+	Dir string `yaml:"dir" toml:"dir"`
+	// BaseURL 文件的访问地址前缀。以 / 开头时由本服务直接提供文件，
+	// 也可以是交给 nginx 或 CDN 提供文件的完整地址
+	BaseURL string `yaml:"base_url" toml:"base_url"`
+}
+
+// S3StorageConfig S3 兼容对象存储（AWS S3、MinIO 等）配置
+type S3StorageConfig struct {
+	// Endpoint 不带协议的服务地址，例如 s3.amazonaws.com 或 localhost:9000
+	Endpoint  string `yaml:"endpoint" toml:"endpoint"`
+	Region    string `yaml:"region" toml:"region"`
+	Bucket    string `yaml:"bucket" toml:"bucket"`
+	AccessKey string `yaml:"access_key" toml:"access_key"`
+	SecretKey string `yaml:"secret_key" toml:"secret_key"`
+	UseSSL    bool   `yaml:"use_ssl" toml:"use_ssl"`
+	// BaseURL 文件的公开访问地址前缀，为空时使用 endpoint/bucket
+	BaseURL string `yaml:"base_url" toml:"base_url"`
+}
+
+// MailConfig 邮件发送配置，用于邮箱验证和找回密码
+type MailConfig struct {
+	// Driver 发送方式：maildir 把邮件写入本地目录，log 只写入日志，smtp 通过 SMTP 服务器发送
+	Driver string `yaml:"driver" toml:"driver"`
+	From   string `yaml:"from" toml:"from"`
+	// BaseURL 前端页面地址，邮件中的链接为 BaseURL/verify-email?token=... 和 BaseURL/reset-password?token=...
+	BaseURL string `yaml:"base_url" toml:"base_url"`
+	// TokenSecret 邮件中验证令牌的签名密钥，为空时由 jwt.secret 派生
+	TokenSecret string `yaml:"token_secret" toml:"token_secret"`
+	// VerifyExpiry 邮箱验证链接的有效期，ResetExpiry 重置密码链接的有效期
+	VerifyExpiry Duration      `yaml:"verify_expiry" toml:"verify_expiry"`
+	ResetExpiry  Duration      `yaml:"reset_expiry" toml:"reset_expiry"`
+	Maildir      MaildirConfig `yaml:"maildir" toml:"maildir"`
+	SMTP         SMTPConfig    `yaml:"smtp" toml:"smtp"`
+}
+
+// MaildirConfig 把邮件按 Maildir 格式写入本地目录，每封邮件一个文件
+type MaildirConfig struct {
+	Dir string `yaml:"dir" toml:"dir"`
+}
+
+// SMTPConfig SMTP 服务器配置，端口 465 使用 TLS，其他端口在服务器支持时使用 STARTTLS
+type SMTPConfig struct {
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"`
+	Username string `yaml:"username" toml:"username"`
+	Password string `yaml:"password" toml:"password"`
+}
+
+// RateLimitConfig 限流和登录失败锁定配置
+type RateLimitConfig struct {
+	// Enabled 是否启用请求限流，登录失败锁定由 Lockout.MaxFailures 单独控制
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Store 限流状态的存储：memory 或 redis，多实例部署时应使用 redis
+	Store string      `yaml:"store" toml:"store"`
+	Redis RedisConfig `yaml:"redis" toml:"redis"`
+	// IP 每个 IP 对所有 /api 接口的限制
+	IP LimitConfig `yaml:"ip" toml:"ip"`
+	// User 每个登录用户对需要认证的接口的限制
+	User LimitConfig `yaml:"user" toml:"user"`
+	// Auth 每个 IP 对登录、注册、找回密码等接口的限制，应比 IP 严格
+	Auth    LimitConfig   `yaml:"auth" toml:"auth"`
+	Lockout LockoutConfig `yaml:"lockout" toml:"lockout"`
+}
+
+// LimitConfig 令牌桶参数：每秒补充 Rate 个请求额度，最多累积 Burst 个
+type LimitConfig struct {
+	Rate  float64 `yaml:"rate" toml:"rate"`
+	Burst int     `yaml:"burst" toml:"burst"`
+}
+
+// RedisConfig Redis 兼容服务的连接配置
+type RedisConfig struct {
+	Addr     string `yaml:"addr" toml:"addr"`
+	Password string `yaml:"password" toml:"password"`
+	DB       int    `yaml:"db" toml:"db"`
+}
+
+// LockoutConfig 登录失败锁定配置：同一账号连续失败 MaxFailures 次后锁定 BaseDelay，
+// 之后每次失败锁定时间翻倍，最长 MaxDelay；Window 内没有新的失败时计数清零
+type LockoutConfig struct {
+	// MaxFailures 为 0 时不锁定
+	MaxFailures int      `yaml:"max_failures" toml:"max_failures"`
+	BaseDelay   Duration `yaml:"base_delay" toml:"base_delay"`
+	MaxDelay    Duration `yaml:"max_delay" toml:"max_delay"`
+	Window      Duration `yaml:"window" toml:"window"`
+}
+
+// LogConfig 日志配置
+type LogConfig struct {
+	Level string `yaml:"level" toml:"level"`
+	// Format 日志格式：json 便于日志系统采集，text 便于本地阅读
+	Format string `yaml:"format" toml:"format"`
+}
+
+// TracingConfig OpenTelemetry 链路追踪配置，启用后为每个请求和数据库查询记录 span
+type TracingConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Exporter span 的导出方式：otlp 通过 OTLP/HTTP 发送到 Collector，stdout 输出到标准输出
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	// Endpoint OTLP/HTTP 接收地址（host:port）
+	Endpoint string `yaml:"endpoint" toml:"endpoint"`
+	// Insecure 为 true 时使用 HTTP 而不是 HTTPS 连接 Endpoint
+	Insecure    bool   `yaml:"insecure" toml:"insecure"`
+	ServiceName string `yaml:"service_name" toml:"service_name"`
+	// SampleRatio 采样比例，0 到 1 之间；请求已带有上游的采样决定时沿用上游的决定
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+// Duration 支持 "30m"、"24h" 这类写法的时长，可直接用于 YAML/TOML
+type Duration time.Duration
+
+// UnmarshalText 解析 time.ParseDuration 格式的字符串
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText 输出 time.Duration 的字符串形式
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Std 返回标准库的 time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// 日志级别
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+// 日志格式
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// 链路追踪导出方式
+const (
+	TracingOTLP   = "otlp"
+	TracingStdout = "stdout"
+)
+
+// 数据库驱动
+const (
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// JWT 签名算法
+const (
+	JWTHS256 = "HS256"
+	JWTRS256 = "RS256"
+	JWTEdDSA = "EdDSA"
+)
+
+// DefaultJWTKeyID 由 jwt.secret 配置的密钥的 id
+const DefaultJWTKeyID = "default"
+
+// 存储后端
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// 邮件发送方式
+const (
+	MailMaildir = "maildir"
+	MailLog     = "log"
+	MailSMTP    = "smtp"
+)
+
+// 限流状态存储
+const (
+	RateLimitMemory = "memory"
+	RateLimitRedis  = "redis"
+)
+
+// minSecretLength JWT 密钥和邮件令牌密钥的最小长度（字节）
+const minSecretLength = 32
+
+// Default 返回默认配置；DSN 和 JWT 密钥没有默认值，必须显式提供
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr: ":8080",
+		},
+		Database: DatabaseConfig{
+			Driver:          DriverMySQL,
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: Duration(time.Hour),
+			AutoMigrate:     true,
+		},
+		JWT: JWTConfig{
+			Issuer:        "blog",
+			Audience:      "blog-api",
+			Expiry:        Duration(15 * time.Minute),
+			RefreshExpiry: Duration(30 * 24 * time.Hour),
+		},
+		Storage: StorageConfig{
+			Driver:        StorageLocal,
+			MaxUploadSize: 10 << 20,
+			Local: LocalStorageConfig{
+				Dir:     "uploads",
+				BaseURL: "/uploads",
+			},
+			S3: S3StorageConfig{
+				Region: "us-east-1",
+				UseSSL: true,
+			},
+		},
+		Mail: MailConfig{
+			Driver:       MailMaildir,
+			From:         "blog@localhost",
+			BaseURL:      "http://localhost:8080",
+			VerifyExpiry: Duration(48 * time.Hour),
+			ResetExpiry:  Duration(time.Hour),
+			Maildir: MaildirConfig{
+				Dir: "maildir",
+			},
+			SMTP: SMTPConfig{
+				Port: 587,
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Store:   RateLimitMemory,
+			Redis: RedisConfig{
+				Addr: "localhost:6379",
+			},
+			IP:   LimitConfig{Rate: 10, Burst: 50},
+			User: LimitConfig{Rate: 5, Burst: 30},
+			Auth: LimitConfig{Rate: 0.2, Burst: 10},
+			Lockout: LockoutConfig{
+				MaxFailures: 5,
+				BaseDelay:   Duration(time.Minute),
+				MaxDelay:    Duration(30 * time.Minute),
+				Window:      Duration(time.Hour),
+			},
+		},
+		Log: LogConfig{
+			Level:  LogLevelInfo,
+			Format: LogFormatJSON,
+		},
+		Tracing: TracingConfig{
+			Exporter:    TracingOTLP,
+			Endpoint:    "localhost:4318",
+			Insecure:    true,
+			ServiceName: "blog",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// Load 按 默认值 -> 配置文件 -> 环境变量 的顺序加载配置并校验。
+// path 为空时读取 BLOG_CONFIG 环境变量，两者都为空则不读取配置文件。
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv("BLOG_CONFIG")
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := loadEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadFile 根据扩展名以 YAML 或 TOML 解析配置文件
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: read %s: %w", path, err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("config: unsupported config file extension %q (want .yaml, .yml or .toml)", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config: parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv 用 BLOG_* 环境变量覆盖配置
+func loadEnv(cfg *Config) error {
+	var errs []error
+
+	setString("BLOG_SERVER_ADDR", &cfg.Server.Addr)
+	setStrings("BLOG_SERVER_TRUSTED_PROXIES", &cfg.Server.TrustedProxies)
+	setString("BLOG_DB_DRIVER", &cfg.Database.Driver)
+	setString("BLOG_DB_DSN", &cfg.Database.DSN)
+	errs = append(errs,
+		setInt("BLOG_DB_MAX_OPEN_CONNS", &cfg.Database.MaxOpenConns),
+		setInt("BLOG_DB_MAX_IDLE_CONNS", &cfg.Database.MaxIdleConns),
+		setDuration("BLOG_DB_CONN_MAX_LIFETIME", &cfg.Database.ConnMaxLifetime),
+		setBool("BLOG_DB_AUTO_MIGRATE", &cfg.Database.AutoMigrate),
+	)
+	setString("BLOG_JWT_SECRET", &cfg.JWT.Secret)
+	setString("BLOG_JWT_SIGNING_KEY", &cfg.JWT.SigningKey)
+	setString("BLOG_JWT_ISSUER", &cfg.JWT.Issuer)
+	setString("BLOG_JWT_AUDIENCE", &cfg.JWT.Audience)
+	errs = append(errs,
+		setDuration("BLOG_JWT_EXPIRY", &cfg.JWT.Expiry),
+		setDuration("BLOG_JWT_REFRESH_EXPIRY", &cfg.JWT.RefreshExpiry),
+	)
+	setString("BLOG_STORAGE_DRIVER", &cfg.Storage.Driver)
+	errs = append(errs, setInt64("BLOG_STORAGE_MAX_UPLOAD_SIZE", &cfg.Storage.MaxUploadSize))
+	setString("BLOG_STORAGE_LOCAL_DIR", &cfg.Storage.Local.Dir)
+	setString("BLOG_STORAGE_LOCAL_BASE_URL", &cfg.Storage.Local.BaseURL)
+	setString("BLOG_STORAGE_S3_ENDPOINT", &cfg.Storage.S3.Endpoint)
+	setString("BLOG_STORAGE_S3_REGION", &cfg.Storage.S3.Region)
+	setString("BLOG_STORAGE_S3_BUCKET", &cfg.Storage.S3.Bucket)
+	setString("BLOG_STORAGE_S3_ACCESS_KEY", &cfg.Storage.S3.AccessKey)
+	setString("BLOG_STORAGE_S3_SECRET_KEY", &cfg.Storage.S3.SecretKey)
+	errs = append(errs, setBool("BLOG_STORAGE_S3_USE_SSL", &cfg.Storage.S3.UseSSL))
+	setString("BLOG_STORAGE_S3_BASE_URL", &cfg.Storage.S3.BaseURL)
+	setString("BLOG_MAIL_DRIVER", &cfg.Mail.Driver)
+	setString("BLOG_MAIL_FROM", &cfg.Mail.From)
+	setString("BLOG_MAIL_BASE_URL", &cfg.Mail.BaseURL)
+	setString("BLOG_MAIL_TOKEN_SECRET", &cfg.Mail.TokenSecret)
+	errs = append(errs,
+		setDuration("BLOG_MAIL_VERIFY_EXPIRY", &cfg.Mail.VerifyExpiry),
+		setDuration("BLOG_MAIL_RESET_EXPIRY", &cfg.Mail.ResetExpiry),
+	)
+	setString("BLOG_MAIL_MAILDIR_DIR", &cfg.Mail.Maildir.Dir)
+	setString("BLOG_MAIL_SMTP_HOST", &cfg.Mail.SMTP.Host)
+	errs = append(errs, setInt("BLOG_MAIL_SMTP_PORT", &cfg.Mail.SMTP.Port))
+	setString("BLOG_MAIL_SMTP_USERNAME", &cfg.Mail.SMTP.Username)
+	setString("BLOG_MAIL_SMTP_PASSWORD", &cfg.Mail.SMTP.Password)
+	errs = append(errs, setBool("BLOG_RATE_LIMIT_ENABLED", &cfg.RateLimit.Enabled))
+	setString("BLOG_RATE_LIMIT_STORE", &cfg.RateLimit.Store)
+	setString("BLOG_RATE_LIMIT_REDIS_ADDR", &cfg.RateLimit.Redis.Addr)
+	setString("BLOG_RATE_LIMIT_REDIS_PASSWORD", &cfg.RateLimit.Redis.Password)
+	errs = append(errs,
+		setInt("BLOG_RATE_LIMIT_REDIS_DB", &cfg.RateLimit.Redis.DB),
+		setFloat("BLOG_RATE_LIMIT_IP_RATE", &cfg.RateLimit.IP.Rate),
+		setInt("BLOG_RATE_LIMIT_IP_BURST", &cfg.RateLimit.IP.Burst),
+		setFloat("BLOG_RATE_LIMIT_USER_RATE", &cfg.RateLimit.User.Rate),
+		setInt("BLOG_RATE_LIMIT_USER_BURST", &cfg.RateLimit.User.Burst),
+		setFloat("BLOG_RATE_LIMIT_AUTH_RATE", &cfg.RateLimit.Auth.Rate),
+		setInt("BLOG_RATE_LIMIT_AUTH_BURST", &cfg.RateLimit.Auth.Burst),
+		setInt("BLOG_RATE_LIMIT_LOCKOUT_MAX_FAILURES", &cfg.RateLimit.Lockout.MaxFailures),
+		setDuration("BLOG_RATE_LIMIT_LOCKOUT_BASE_DELAY", &cfg.RateLimit.Lockout.BaseDelay),
+		setDuration("BLOG_RATE_LIMIT_LOCKOUT_MAX_DELAY", &cfg.RateLimit.Lockout.MaxDelay),
+		setDuration("BLOG_RATE_LIMIT_LOCKOUT_WINDOW", &cfg.RateLimit.Lockout.Window),
+	)
+	setString("BLOG_LOG_LEVEL", &cfg.Log.Level)
+	setString("BLOG_LOG_FORMAT", &cfg.Log.Format)
+	errs = append(errs, setBool("BLOG_TRACING_ENABLED", &cfg.Tracing.Enabled))
+	setString("BLOG_TRACING_EXPORTER", &cfg.Tracing.Exporter)
+	setString("BLOG_TRACING_ENDPOINT", &cfg.Tracing.Endpoint)
+	errs = append(errs, setBool("BLOG_TRACING_INSECURE", &cfg.Tracing.Insecure))
+	setString("BLOG_TRACING_SERVICE_NAME", &cfg.Tracing.ServiceName)
+	errs = append(errs, setFloat("BLOG_TRACING_SAMPLE_RATIO", &cfg.Tracing.SampleRatio))
+
+	return errors.Join(errs...)
+}
+
+func setString(key string, dst *string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+// setStrings 解析逗号分隔的列表，空字符串表示空列表
+func setStrings(key string, dst *[]string) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	*dst = nil
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*dst = append(*dst, item)
+		}
+	}
+}
+
+func setInt(key string, dst *int) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("config: %s must be an integer, got %q", key, v)
+	}
+	*dst = n
+	return nil
+}
+
+func setInt64(key string, dst *int64) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return fmt.Errorf("config: %s must be an integer, got %q", key, v)
+	}
+	*dst = n
+	return nil
+}
+
+func setFloat(key string, dst *float64) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return fmt.Errorf("config: %s must be a number, got %q", key, v)
+	}
+	*dst = f
+	return nil
+}
+
+func setBool(key string, dst *bool) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("config: %s must be a boolean, got %q", key, v)
+	}
+	*dst = b
+	return nil
+}
+
+func setDuration(key string, dst *Duration) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	if err := dst.UnmarshalText([]byte(v)); err != nil {
+		return fmt.Errorf("config: %s must be a duration like \"30m\" or \"24h\", got %q", key, v)
+	}
+	return nil
+}
+
+// Validate 校验配置，一次性返回所有错误
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("config: server.addr (BLOG_SERVER_ADDR) is required"))
+	}
+
+	switch c.Database.Driver {
+	case DriverMySQL, DriverSQLite, DriverPostgres:
+	default:
+		errs = append(errs, fmt.Errorf("config: database.driver must be one of mysql, sqlite, postgres, got %q", c.Database.Driver))
+	}
+	if c.Database.DSN == "" {
+		errs = append(errs, errors.New("config: database.dsn (BLOG_DB_DSN) is required"))
+	}
+	if c.Database.MaxOpenConns < 0 {
+		errs = append(errs, errors.New("config: database.max_open_conns must not be negative"))
+	}
+	if c.Database.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("config: database.max_idle_conns must not be negative"))
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("config: database.max_idle_conns (%d) must not exceed max_open_conns (%d)",
+			c.Database.MaxIdleConns, c.Database.MaxOpenConns))
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		errs = append(errs, errors.New("config: database.conn_max_lifetime must not be negative"))
+	}
+
+	errs = append(errs, c.JWT.validateKeys()...)
+	if c.JWT.Issuer == "" || c.JWT.Audience == "" {
+		errs = append(errs, errors.New("config: jwt.issuer and jwt.audience are required"))
+	}
+	if c.JWT.Expiry <= 0 {
+		errs = append(errs, errors.New("config: jwt.expiry must be positive"))
+	}
+	if c.JWT.RefreshExpiry <= c.JWT.Expiry {
+		errs = append(errs, errors.New("config: jwt.refresh_expiry must be longer than jwt.expiry"))
+	}
+
+	switch c.Storage.Driver {
+	case StorageLocal:
+		if c.Storage.Local.Dir == "" {
+			errs = append(errs, errors.New("config: storage.local.dir (BLOG_STORAGE_LOCAL_DIR) is required"))
+		}
+		if c.Storage.Local.BaseURL == "" {
+			errs = append(errs, errors.New("config: storage.local.base_url (BLOG_STORAGE_LOCAL_BASE_URL) is required"))
+		}
+	case StorageS3:
+		if c.Storage.S3.Endpoint == "" {
+			errs = append(errs, errors.New("config: storage.s3.endpoint (BLOG_STORAGE_S3_ENDPOINT) is required"))
+		}
+		if c.Storage.S3.Bucket == "" {
+			errs = append(errs, errors.New("config: storage.s3.bucket (BLOG_STORAGE_S3_BUCKET) is required"))
+		}
+		if c.Storage.S3.AccessKey == "" || c.Storage.S3.SecretKey == "" {
+			errs = append(errs, errors.New("config: storage.s3.access_key and storage.s3.secret_key are required"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("config: storage.driver must be one of local, s3, got %q", c.Storage.Driver))
+	}
+	if c.Storage.MaxUploadSize <= 0 {
+		errs = append(errs, errors.New("config: storage.max_upload_size must be positive"))
+	}
+
+	switch c.Mail.Driver {
+	case MailMaildir:
+		if c.Mail.Maildir.Dir == "" {
+			errs = append(errs, errors.New("config: mail.maildir.dir (BLOG_MAIL_MAILDIR_DIR) is required"))
+		}
+	case MailLog:
+	case MailSMTP:
+		if c.Mail.SMTP.Host == "" {
+			errs = append(errs, errors.New("config: mail.smtp.host (BLOG_MAIL_SMTP_HOST) is required"))
+		}
+		if c.Mail.SMTP.Port <= 0 || c.Mail.SMTP.Port > 65535 {
+			errs = append(errs, fmt.Errorf("config: mail.smtp.port must be between 1 and 65535, got %d", c.Mail.SMTP.Port))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("config: mail.driver must be one of maildir, log, smtp, got %q", c.Mail.Driver))
+	}
+	if c.Mail.From == "" {
+		errs = append(errs, errors.New("config: mail.from (BLOG_MAIL_FROM) is required"))
+	}
+	if c.Mail.BaseURL == "" {
+		errs = append(errs, errors.New("config: mail.base_url (BLOG_MAIL_BASE_URL) is required"))
+	}
+	if c.Mail.TokenSecret == "" && c.JWT.Secret == "" {
+		errs = append(errs, errors.New("config: mail.token_secret (BLOG_MAIL_TOKEN_SECRET) is required when jwt.secret is not set"))
+	} else if c.Mail.TokenSecret != "" && len(c.Mail.TokenSecret) < minSecretLength {
+		errs = append(errs, fmt.Errorf("config: mail.token_secret must be at least %d bytes", minSecretLength))
+	}
+	if c.Mail.VerifyExpiry <= 0 || c.Mail.ResetExpiry <= 0 {
+		errs = append(errs, errors.New("config: mail.verify_expiry and mail.reset_expiry must be positive"))
+	}
+
+	switch c.RateLimit.Store {
+	case RateLimitMemory:
+	case RateLimitRedis:
+		if c.RateLimit.Redis.Addr == "" {
+			errs = append(errs, errors.New("config: rate_limit.redis.addr (BLOG_RATE_LIMIT_REDIS_ADDR) is required"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("config: rate_limit.store must be one of memory, redis, got %q", c.RateLimit.Store))
+	}
+	if c.RateLimit.Enabled {
+		limits := []struct {
+			name  string
+			limit LimitConfig
+		}{{"ip", c.RateLimit.IP}, {"user", c.RateLimit.User}, {"auth", c.RateLimit.Auth}}
+		for _, l := range limits {
+			if l.limit.Rate <= 0 || l.limit.Burst < 1 {
+				errs = append(errs, fmt.Errorf("config: rate_limit.%s needs a positive rate and a burst of at least 1", l.name))
+			}
+		}
+	}
+	if lockout := c.RateLimit.Lockout; lockout.MaxFailures < 0 {
+		errs = append(errs, errors.New("config: rate_limit.lockout.max_failures must not be negative"))
+	} else if lockout.MaxFailures > 0 {
+		if lockout.BaseDelay <= 0 || lockout.MaxDelay < lockout.BaseDelay {
+			errs = append(errs, errors.New("config: rate_limit.lockout.base_delay must be positive and not exceed max_delay"))
+		}
+		if lockout.Window < lockout.MaxDelay {
+			errs = append(errs, errors.New("config: rate_limit.lockout.window must not be shorter than max_delay"))
+		}
+	}
+
+	switch c.Log.Level {
+	case LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
+	default:
+		errs = append(errs, fmt.Errorf("config: log.level must be one of debug, info, warn, error, got %q", c.Log.Level))
+	}
+	switch c.Log.Format {
+	case LogFormatJSON, LogFormatText:
+	default:
+		errs = append(errs, fmt.Errorf("config: log.format must be one of json, text, got %q", c.Log.Format))
+	}
+
+	if c.Tracing.Enabled {
+		switch c.Tracing.Exporter {
+		case TracingOTLP:
+			if c.Tracing.Endpoint == "" {
+				errs = append(errs, errors.New("config: tracing.endpoint (BLOG_TRACING_ENDPOINT) is required"))
+			}
+		case TracingStdout:
+		default:
+			errs = append(errs, fmt.Errorf("config: tracing.exporter must be one of otlp, stdout, got %q", c.Tracing.Exporter))
+		}
+		if c.Tracing.ServiceName == "" {
+			errs = append(errs, errors.New("config: tracing.service_name (BLOG_TRACING_SERVICE_NAME) is required"))
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			errs = append(errs, fmt.Errorf("config: tracing.sample_ratio must be between 0 and 1, got %g", c.Tracing.SampleRatio))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// AllKeys 返回所有签名密钥，jwt.secret 作为 id 为 "default" 的 HS256 密钥排在最后
+func (c JWTConfig) AllKeys() []JWTKeyConfig {
+	keys := append([]JWTKeyConfig(nil), c.Keys...)
+	if c.Secret != "" {
+		keys = append(keys, JWTKeyConfig{ID: DefaultJWTKeyID, Algorithm: JWTHS256, Secret: c.Secret})
+	}
+	return keys
+}
+
+// SigningKeyID 返回签发新令牌使用的密钥 id
+func (c JWTConfig) SigningKeyID() string {
+	if c.SigningKey != "" {
+		return c.SigningKey
+	}
+	if keys := c.AllKeys(); len(keys) > 0 {
+		return keys[0].ID
+	}
+	return ""
+}
+
+// validateKeys 校验签名密钥：id 唯一、算法和密钥材料匹配，签发密钥存在且带有私钥
+func (c JWTConfig) validateKeys() []error {
+	if c.Secret == "" && len(c.Keys) == 0 {
+		return []error{errors.New("config: jwt.secret (BLOG_JWT_SECRET) or jwt.keys is required")}
+	}
+
+	var errs []error
+	seen := make(map[string]bool)
+	if c.Secret != "" {
+		if len(c.Secret) < minSecretLength {
+			errs = append(errs, fmt.Errorf("config: jwt.secret must be at least %d bytes", minSecretLength))
+		}
+		seen[DefaultJWTKeyID] = true
+	}
+	for i, key := range c.Keys {
+		name := fmt.Sprintf("jwt.keys[%d]", i)
+		if key.ID == "" {
+			errs = append(errs, fmt.Errorf("config: %s.id is required", name))
+		} else if seen[key.ID] {
+			errs = append(errs, fmt.Errorf("config: %s.id %q is already used", name, key.ID))
+		}
+		seen[key.ID] = true
+
+		switch key.Algorithm {
+		case JWTHS256:
+			if len(key.Secret) < minSecretLength {
+				errs = append(errs, fmt.Errorf("config: %s.secret must be at least %d bytes", name, minSecretLength))
+			}
+		case JWTRS256, JWTEdDSA:
+			if key.PrivateKeyFile == "" && key.PublicKeyFile == "" {
+				errs = append(errs, fmt.Errorf("config: %s needs private_key_file or public_key_file", name))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("config: %s.algorithm must be one of HS256, RS256, EdDSA, got %q", name, key.Algorithm))
+		}
+	}
+
+	signing := c.SigningKeyID()
+	for _, key := range c.AllKeys() {
+		if key.ID == signing {
+			if key.Algorithm != JWTHS256 && key.PrivateKeyFile == "" {
+				errs = append(errs, fmt.Errorf("config: jwt signing key %q has no private_key_file", signing))
+			}
+			return errs
+		}
+	}
+	return append(errs, fmt.Errorf("config: jwt.signing_key %q does not match any key", signing))
+}
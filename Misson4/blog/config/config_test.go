@@ -0,0 +1,150 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// validConfig 在默认值基础上补齐必填项，能通过校验
+func validConfig() Config {
+	cfg := Default()
+	cfg.Database.DSN = "blog.db"
+	cfg.JWT.Secret = testSecret
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   []string // 错误信息中应包含的片段，为空表示校验通过
+	}{
+		{"defaults with required fields", func(c *Config) {}, nil},
+		{"missing dsn", func(c *Config) { c.Database.DSN = "" }, []string{"database.dsn"}},
+		{"unknown driver", func(c *Config) { c.Database.Driver = "oracle" }, []string{"database.driver"}},
+		{"idle exceeds open", func(c *Config) { c.Database.MaxIdleConns = 100 }, []string{"max_idle_conns"}},
+		{"missing jwt secret", func(c *Config) { c.JWT.Secret = "" }, []string{"jwt.secret"}},
+		{"short jwt secret", func(c *Config) { c.JWT.Secret = "short" }, []string{"at least 32 bytes"}},
+		{"refresh not longer than access", func(c *Config) { c.JWT.RefreshExpiry = c.JWT.Expiry }, []string{"refresh_expiry"}},
+		{"duplicate key id", func(c *Config) {
+			c.JWT.Keys = []JWTKeyConfig{{ID: DefaultJWTKeyID, Algorithm: JWTHS256, Secret: testSecret}}
+		}, []string{"already used"}},
+		{"unknown signing key", func(c *Config) { c.JWT.SigningKey = "nope" }, []string{"does not match any key"}},
+		{"public key cannot sign", func(c *Config) {
+			c.JWT.Keys = []JWTKeyConfig{{ID: "rsa", Algorithm: JWTRS256, PublicKeyFile: "pub.pem"}}
+			c.JWT.SigningKey = "rsa"
+		}, []string{"no private_key_file"}},
+		{"s3 without bucket", func(c *Config) {
+			c.Storage.Driver = StorageS3
+			c.Storage.S3.Endpoint = "s3.example.com"
+			c.Storage.S3.AccessKey, c.Storage.S3.SecretKey = "a", "b"
+		}, []string{"storage.s3.bucket"}},
+		{"smtp without host", func(c *Config) { c.Mail.Driver = MailSMTP }, []string{"mail.smtp.host"}},
+		{"rate limit without burst", func(c *Config) { c.RateLimit.IP.Burst = 0 }, []string{"rate_limit.ip"}},
+		{"rate limit disabled", func(c *Config) { c.RateLimit.Enabled = false; c.RateLimit.IP.Burst = 0 }, nil},
+		{"lockout window too short", func(c *Config) { c.RateLimit.Lockout.Window = Duration(time.Minute) }, []string{"lockout.window"}},
+		{"bad log level", func(c *Config) { c.Log.Level = "verbose" }, []string{"log.level"}},
+		{"tracing sample ratio", func(c *Config) { c.Tracing.Enabled = true; c.Tracing.SampleRatio = 2 }, []string{"sample_ratio"}},
+		{"all errors reported", func(c *Config) { c.Database.DSN = ""; c.Server.Addr = "" }, []string{"database.dsn", "server.addr"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want errors mentioning %q", tt.want)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("Validate() = %v, want it to mention %q", err, w)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "blog.yaml")
+	data := "server:\n  addr: \":9000\"\ndatabase:\n  driver: sqlite\n  dsn: file.db\njwt:\n  secret: " + testSecret + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BLOG_DB_DSN", "env.db")
+	t.Setenv("BLOG_JWT_EXPIRY", "5m")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Addr != ":9000" || cfg.Database.Driver != DriverSQLite {
+		t.Errorf("file values not applied: addr %q, driver %q", cfg.Server.Addr, cfg.Database.Driver)
+	}
+	if cfg.Database.DSN != "env.db" || cfg.JWT.Expiry.Std() != 5*time.Minute {
+		t.Errorf("env values not applied: dsn %q, expiry %v", cfg.Database.DSN, cfg.JWT.Expiry.Std())
+	}
+	if cfg.JWT.Issuer != "blog" {
+		t.Errorf("default issuer = %q, want %q", cfg.JWT.Issuer, "blog")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		file string
+		want string
+	}{
+		{"bad duration", map[string]string{"BLOG_JWT_EXPIRY": "soon"}, "", "BLOG_JWT_EXPIRY"},
+		{"bad extension", nil, "blog.ini", "unsupported config file extension"},
+		{"missing file", nil, "missing.yaml", "read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BLOG_CONFIG", "")
+			t.Setenv("BLOG_DB_DSN", "blog.db")
+			t.Setenv("BLOG_JWT_SECRET", testSecret)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			path := ""
+			if tt.file != "" {
+				path = filepath.Join(t.TempDir(), tt.file)
+				if tt.file != "missing.yaml" {
+					if err := os.WriteFile(path, nil, 0o600); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load() = %v, want error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuration(t *testing.T) {
+	var d Duration
+	if err := d.UnmarshalText([]byte("90s")); err != nil || d.Std() != 90*time.Second {
+		t.Fatalf("UnmarshalText(90s) = %v, %v", d.Std(), err)
+	}
+	if err := d.UnmarshalText([]byte("soon")); err == nil {
+		t.Fatal("UnmarshalText(soon) succeeded")
+	}
+	if text, _ := Duration(time.Minute).MarshalText(); string(text) != "1m0s" {
+		t.Errorf("MarshalText() = %q, want %q", text, "1m0s")
+	}
+}
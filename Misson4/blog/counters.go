@@ -0,0 +1,30 @@
+package main
+
+import (
+	"blog/database"
+	"blog/repository"
+	"context"
+	"fmt"
+)
+
+// runCounters 处理 counters 子命令
+func runCounters(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("counters: missing subcommand (reconcile)")
+	}
+
+	switch args[0] {
+	case "reconcile":
+		fixes, err := repository.NewCounterRepository(database.DB).Reconcile(context.Background())
+		if err != nil {
+			return fmt.Errorf("counters reconcile: %w", err)
+		}
+		fmt.Printf("Fixed post_count of %d users\n", fixes.UserPostCounts)
+		fmt.Printf("Fixed comment_count of %d posts\n", fixes.PostCommentCounts)
+		fmt.Printf("Fixed like_count of %d posts\n", fixes.PostLikeCounts)
+		return nil
+
+	default:
+		return fmt.Errorf("counters: unknown subcommand %q (want reconcile)", args[0])
+	}
+}
@@ -1,35 +1,90 @@
 package database
 
 import (
-	"blog/models"
-	"log"
+	"blog/config"
+	"fmt"
+	"log/slog"
+	"os"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
 
-func InitDB() {
+func InitDB(cfg config.DatabaseConfig, logLevel string) {
 	var err error
 
-	dsn := "root:Zhaoyang@100297@tcp(localhost:3306)/mysql?charset=utf8mb4&parseTime=True&loc=Local"
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	DB, err = Open(cfg, logLevel)
 	if err != nil {
-		log.Fatal("failed to connect database: ", err)
+		slog.Error("failed to connect database", "error", err)
+		os.Exit(1)
 	}
 
-	log.Println("Database connected successfully")
+	slog.Info("database connected", "driver", cfg.Driver)
+}
 
-	// 删除旧表（如果存在）以避免外键约束冲突
-	// 注意：这会删除所有数据，仅用于开发环境
-	DB.Migrator().DropTable(&models.Comment{}, &models.Post{}, &models.User{})
+// Open 按配置的驱动打开数据库并设置连接池。
+// 测试和本地演示可以用 sqlite 驱动配合文件路径或 ":memory:" 直接调用。
+func Open(cfg config.DatabaseConfig, logLevel string) (*gorm.DB, error) {
+	dialector, err := dialectorFor(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	// 自动迁移模型
-	err = DB.AutoMigrate(&models.User{}, &models.Post{}, &models.Comment{})
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: gormLogger{level: gormLogLevel(logLevel)},
+	})
 	if err != nil {
-		log.Fatal("failed to migrate database: ", err)
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("get database handle: %w", err)
+	}
+
+	if cfg.Driver == config.DriverSQLite {
+		// SQLite 同一时间只允许一个写连接，内存库每个连接都是独立的数据库，
+		// 因此固定使用单个连接，并开启默认关闭的外键约束
+		sqlDB.SetMaxOpenConns(1)
+		if err := db.Exec("PRAGMA foreign_keys = ON").Error; err != nil {
+			return nil, fmt.Errorf("enable sqlite foreign keys: %w", err)
+		}
+	} else {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime.Std())
 	}
 
-	log.Println("Database migration completed")
+	return db, nil
+}
+
+// dialectorFor 根据驱动名返回对应的 GORM Dialector
+func dialectorFor(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case config.DriverMySQL:
+		return mysql.Open(cfg.DSN), nil
+	case config.DriverSQLite:
+		return sqlite.Open(cfg.DSN), nil
+	case config.DriverPostgres:
+		return postgres.Open(cfg.DSN), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+}
+
+// gormLogLevel 将配置中的日志级别映射为 GORM 日志级别
+func gormLogLevel(level string) logger.LogLevel {
+	switch level {
+	case config.LogLevelDebug:
+		return logger.Info
+	case config.LogLevelInfo, config.LogLevelWarn:
+		return logger.Warn
+	default:
+		return logger.Error
+	}
 }
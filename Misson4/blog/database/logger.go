@@ -0,0 +1,67 @@
+package database
+
+import (
+	"blog/logging"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold 超过这个耗时的查询按慢查询记录警告日志
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger 把 GORM 的日志写入查询 context 中的 logger，请求中执行的查询日志带有请求 ID
+type gormLogger struct {
+	level logger.LogLevel
+}
+
+// LogMode 返回使用指定级别的 logger
+func (l gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return gormLogger{level: level}
+}
+
+func (l gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		logging.FromContext(ctx).InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		logging.FromContext(ctx).WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		logging.FromContext(ctx).ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Trace 记录执行的 SQL：出错（record not found 除外）时记录错误，慢查询记录警告，
+// Info 级别时记录所有查询
+func (l gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	attrs := func() []any {
+		sql, rows := fc()
+		return []any{slog.String("sql", sql), slog.Int64("rows", rows), slog.Float64("elapsed_ms", float64(elapsed.Microseconds())/1000)}
+	}
+	log := logging.FromContext(ctx)
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		log.ErrorContext(ctx, "sql error", append(attrs(), slog.Any("error", err))...)
+	case elapsed > slowQueryThreshold && l.level >= logger.Warn:
+		log.WarnContext(ctx, "slow sql", attrs()...)
+	case l.level >= logger.Info:
+		log.DebugContext(ctx, "sql", attrs()...)
+	}
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration 一次版本化的数据库变更，Up 和 Down 必须互为逆操作
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration schema_migrations 表中的一条记录，表示某个版本已执行
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName 指定迁移记录表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus 单个迁移的执行状态
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// sortedMigrations 返回按版本号升序排列的迁移，并检查版本号是否重复
+func sortedMigrations() ([]Migration, error) {
+	list := make([]Migration, len(migrations))
+	copy(list, migrations)
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+
+	for i := 1; i < len(list); i++ {
+		if list[i].Version == list[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", list[i].Version)
+		}
+	}
+	return list, nil
+}
+
+// appliedVersions 读取已执行的迁移版本
+func appliedVersions(db *gorm.DB) (map[int]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var rows []SchemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+
+	applied := make(map[int]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// runMigration 在事务中执行一次迁移。
+// SQLite 修改、删除列时会重建整张表，删除旧表会按外键级联删除子表的数据，
+// 因此在 SQLite 上临时关闭外键约束；该 PRAGMA 在事务内无效，只能在事务外设置。
+func runMigration(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if db.Dialector.Name() != "sqlite" {
+		return db.Transaction(fn)
+	}
+
+	if err := db.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+		return err
+	}
+	defer func() {
+		if err := db.Exec("PRAGMA foreign_keys = ON").Error; err != nil {
+			slog.Error("re-enable sqlite foreign keys failed", "error", err)
+		}
+	}()
+	return db.Transaction(fn)
+}
+
+// MigrateUp 按版本顺序执行所有未执行的迁移，返回本次执行的迁移
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	list, err := sortedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range list {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		err := runMigration(db, func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s up: %w", m.Version, m.Name, err)
+		}
+
+		slog.Info("migration applied", "version", m.Version, "name", m.Name)
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrateDown 按版本倒序回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	list, err := sortedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(list) - 1; i >= 0 && len(done) < steps; i-- {
+		m := list[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		err := runMigration(db, func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s down: %w", m.Version, m.Name, err)
+		}
+
+		slog.Info("migration reverted", "version", m.Version, "name", m.Name)
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Status 返回所有已知迁移的执行状态
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	list, err := sortedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(list))
+	for _, m := range list {
+		s := MigrationStatus{Version: m.Version, Name: m.Name}
+		if row, ok := applied[m.Version]; ok {
+			s.Applied = true
+			appliedAt := row.AppliedAt
+			s.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
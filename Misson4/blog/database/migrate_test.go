@@ -0,0 +1,124 @@
+package database_test
+
+import (
+	"blog/config"
+	"blog/database"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// openDB 打开一个空的内存 SQLite 数据库
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(config.DatabaseConfig{Driver: config.DriverSQLite, DSN: ":memory:"}, config.LogLevelError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// appliedCount 返回已执行的迁移数
+func appliedCount(t *testing.T, db *gorm.DB) int {
+	t.Helper()
+	statuses, err := database.Status(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, s := range statuses {
+		if s.Applied {
+			n++
+		}
+	}
+	return n
+}
+
+func TestMigrateUpDownRoundTrip(t *testing.T) {
+	db := openDB(t)
+
+	statuses, err := database.Status(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := len(statuses)
+
+	done, err := database.MigrateUp(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != total || appliedCount(t, db) != total {
+		t.Fatalf("MigrateUp() applied %d of %d migrations", len(done), total)
+	}
+	if done, err := database.MigrateUp(db); err != nil || len(done) != 0 {
+		t.Fatalf("second MigrateUp() = %d migrations, %v; want none", len(done), err)
+	}
+
+	done, err = database.MigrateDown(db, total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != total || appliedCount(t, db) != 0 {
+		t.Fatalf("MigrateDown() reverted %d of %d migrations", len(done), total)
+	}
+	for _, table := range []string{"users", "posts", "comments", "post_slugs", "attachments"} {
+		if db.Migrator().HasTable(table) {
+			t.Errorf("table %s still exists after reverting all migrations", table)
+		}
+	}
+
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatalf("MigrateUp() after MigrateDown(): %v", err)
+	}
+	if appliedCount(t, db) != total {
+		t.Fatalf("re-applied %d of %d migrations", appliedCount(t, db), total)
+	}
+}
+
+func TestMigrateUpBackfillsSlugs(t *testing.T) {
+	db := openDB(t)
+
+	// 先迁移到生成 slug 之前的版本（10），写入同名文章后再执行剩余迁移
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.MigrateDown(db, appliedCount(t, db)-10); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasColumn("posts", "slug") {
+		t.Fatal("posts.slug exists at version 10")
+	}
+
+	if err := db.Exec("INSERT INTO users (id, username, password, email, created_at, updated_at) VALUES (1, 'alice', 'x', 'alice@example.com', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)").Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, title := range []string{"Hello World", "Hello World", "你好"} {
+		if err := db.Exec("INSERT INTO posts (title, content, user_id, created_at, updated_at) VALUES (?, 'x', 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)", title).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+
+	var slugs []string
+	if err := db.Table("posts").Order("id").Pluck("slug", &slugs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(slugs) != 3 || slugs[0] == "" || slugs[0] == slugs[1] || slugs[2] == "" {
+		t.Fatalf("slugs = %q, want three distinct non-empty slugs", slugs)
+	}
+	var history int64
+	if err := db.Table("post_slugs").Count(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if history != 3 {
+		t.Errorf("post_slugs has %d rows, want 3", history)
+	}
+}
@@ -0,0 +1,644 @@
+package database
+
+import (
+	"blog/markdown"
+	"blog/slug"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// migrations 全部数据库迁移，新增迁移时追加到末尾并使用递增的版本号。
+// 迁移中使用函数内定义的结构体快照，而不是 models 包中的模型，
+// 这样模型后续变化不会改变已发布迁移的行为。
+var migrations = []Migration{
+	createInitialTables(),
+	addCommentParentID(),
+	addCommentEditedAt(),
+	createTokenTables(),
+	addUserRole(),
+	addFulltextIndexes(),
+	createTagsAndCategories(),
+	addPostStatus(),
+	createPostRevisions(),
+	addRenderedContent(),
+	addPostSlugs(),
+	createAttachments(),
+	addLikesAndBookmarks(),
+	addCounters(),
+	addUserProfiles(),
+	addEmailVerification(),
+}
+
+// dropColumn 删除 model 中 field 对应的列。
+// GORM 的 SQLite 迁移器通过重建整张表来删除列，会丢失表上的其他索引；
+// SQLite 3.35 起原生支持 DROP COLUMN，这里直接执行。列上的索引需要先删除。
+func dropColumn(tx *gorm.DB, model interface{}, field string) error {
+	if tx.Dialector.Name() != "sqlite" {
+		return tx.Migrator().DropColumn(model, field)
+	}
+
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	f := stmt.Schema.LookUpField(field)
+	if f == nil {
+		return fmt.Errorf("drop column: unknown field %s.%s", stmt.Schema.Name, field)
+	}
+	return tx.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: stmt.Schema.Table}, clause.Column{Name: f.DBName}).Error
+}
+
+// createInitialTables 创建 users、posts、comments 表。
+// 使用 AutoMigrate 实现，对已有这些表的旧库执行是安全的，可以直接纳入版本管理。
+func createInitialTables() Migration {
+	type User struct {
+		ID        uint   `gorm:"primaryKey"`
+		Username  string `gorm:"type:varchar(50);uniqueIndex;not null"`
+		Password  string `gorm:"type:varchar(255);not null"`
+		Email     string `gorm:"type:varchar(100);uniqueIndex;not null"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+		DeletedAt gorm.DeletedAt `gorm:"index"`
+	}
+	type Post struct {
+		ID        uint   `gorm:"primaryKey"`
+		Title     string `gorm:"type:varchar(200);not null"`
+		Content   string `gorm:"type:text;not null"`
+		UserID    uint   `gorm:"not null;index"`
+		User      User   `gorm:"foreignKey:UserID"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+		DeletedAt gorm.DeletedAt `gorm:"index"`
+	}
+	type Comment struct {
+		ID        uint   `gorm:"primaryKey"`
+		Content   string `gorm:"type:text;not null"`
+		UserID    uint   `gorm:"not null;index"`
+		User      User   `gorm:"foreignKey:UserID"`
+		PostID    uint   `gorm:"not null;index"`
+		Post      Post   `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+		DeletedAt gorm.DeletedAt `gorm:"index"`
+	}
+
+	return Migration{
+		Version: 1,
+		Name:    "create_users_posts_comments",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&User{}, &Post{}, &Comment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			// DropTable 按参数倒序删除，依赖其他表的 comments 放在最后以便最先删除
+			return tx.Migrator().DropTable(&User{}, &Post{}, &Comment{})
+		},
+	}
+}
+
+// addCommentParentID 为 comments 增加 parent_id，支持评论的嵌套回复
+func addCommentParentID() Migration {
+	type Comment struct {
+		ParentID *uint `gorm:"index"`
+	}
+
+	return Migration{
+		Version: 2,
+		Name:    "add_comment_parent_id",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&Comment{}, "ParentID"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&Comment{}, "ParentID")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&Comment{}, "ParentID"); err != nil {
+				return err
+			}
+			return dropColumn(tx, &Comment{}, "ParentID")
+		},
+	}
+}
+
+// addCommentEditedAt 为 comments 增加 edited_at，标记被作者编辑过的评论
+func addCommentEditedAt() Migration {
+	type Comment struct {
+		EditedAt *time.Time
+	}
+
+	return Migration{
+		Version: 3,
+		Name:    "add_comment_edited_at",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&Comment{}, "EditedAt")
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumn(tx, &Comment{}, "EditedAt")
+		},
+	}
+}
+
+// createTokenTables 创建 refresh_tokens 和 revoked_tokens，支持刷新令牌轮换和注销
+func createTokenTables() Migration {
+	type RefreshToken struct {
+		ID        uint      `gorm:"primaryKey"`
+		UserID    uint      `gorm:"not null;index"`
+		FamilyID  string    `gorm:"type:varchar(64);not null;index"`
+		TokenHash string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+		ExpiresAt time.Time `gorm:"not null;index"`
+		RevokedAt *time.Time
+		CreatedAt time.Time
+	}
+	type RevokedToken struct {
+		JTI       string    `gorm:"type:varchar(64);primaryKey"`
+		ExpiresAt time.Time `gorm:"not null;index"`
+	}
+
+	return Migration{
+		Version: 4,
+		Name:    "create_token_tables",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&RefreshToken{}, &RevokedToken{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&RefreshToken{}, &RevokedToken{})
+		},
+	}
+}
+
+// addUserRole 为 users 增加 role，已有用户默认为 author
+func addUserRole() Migration {
+	type User struct {
+		Role string `gorm:"type:varchar(20);not null;default:author"`
+	}
+
+	return Migration{
+		Version: 5,
+		Name:    "add_user_role",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&User{}, "Role")
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumn(tx, &User{}, "Role")
+		},
+	}
+}
+
+// addFulltextIndexes 在 MySQL 上为文章和评论创建 FULLTEXT 索引，使用 ngram 分词以支持中文。
+// 其他数据库没有对应的索引，搜索使用进程内的倒排索引，这里不做任何操作。
+func addFulltextIndexes() Migration {
+	return Migration{
+		Version: 6,
+		Name:    "add_fulltext_indexes",
+		Up: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			if err := tx.Exec("CREATE FULLTEXT INDEX idx_posts_fulltext ON posts (title, content) WITH PARSER ngram").Error; err != nil {
+				return err
+			}
+			return tx.Exec("CREATE FULLTEXT INDEX idx_comments_fulltext ON comments (content) WITH PARSER ngram").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			if err := tx.Exec("DROP INDEX idx_comments_fulltext ON comments").Error; err != nil {
+				return err
+			}
+			return tx.Exec("DROP INDEX idx_posts_fulltext ON posts").Error
+		},
+	}
+}
+
+// createTagsAndCategories 创建 tags、categories 和 post_tags，并为 posts 增加 category_id
+func createTagsAndCategories() Migration {
+	type Tag struct {
+		ID        uint   `gorm:"primaryKey"`
+		Name      string `gorm:"type:varchar(50);uniqueIndex;not null"`
+		CreatedAt time.Time
+	}
+	type Category struct {
+		ID          uint   `gorm:"primaryKey"`
+		Name        string `gorm:"type:varchar(50);uniqueIndex;not null"`
+		Description string `gorm:"type:varchar(255)"`
+		CreatedAt   time.Time
+		UpdatedAt   time.Time
+	}
+	type Post struct {
+		ID         uint  `gorm:"primaryKey"`
+		CategoryID *uint `gorm:"index"`
+	}
+	type PostTag struct {
+		PostID uint `gorm:"primaryKey"`
+		Post   Post `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+		TagID  uint `gorm:"primaryKey;index"`
+		Tag    Tag  `gorm:"foreignKey:TagID;constraint:OnDelete:CASCADE"`
+	}
+
+	return Migration{
+		Version: 7,
+		Name:    "create_tags_and_categories",
+		Up: func(tx *gorm.DB) error {
+			// AutoMigrate 会连同 post_tags 依赖的 posts 一起迁移，为其补上 category_id 列和索引
+			return tx.AutoMigrate(&Tag{}, &Category{}, &Post{}, &PostTag{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&Tag{}, &Category{}, &PostTag{}); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropIndex(&Post{}, "CategoryID"); err != nil {
+				return err
+			}
+			return dropColumn(tx, &Post{}, "CategoryID")
+		},
+	}
+}
+
+// addPostStatus 为 posts 增加 status 和 publish_at。
+// 已有文章都视为已发布，发布时间取创建时间。
+func addPostStatus() Migration {
+	type Post struct {
+		Status    string     `gorm:"type:varchar(20);not null;default:published;index"`
+		PublishAt *time.Time `gorm:"index"`
+	}
+
+	return Migration{
+		Version: 8,
+		Name:    "add_post_status",
+		Up: func(tx *gorm.DB) error {
+			for _, field := range []string{"Status", "PublishAt"} {
+				if err := tx.Migrator().AddColumn(&Post{}, field); err != nil {
+					return err
+				}
+				if err := tx.Migrator().CreateIndex(&Post{}, field); err != nil {
+					return err
+				}
+			}
+			return tx.Exec("UPDATE posts SET publish_at = created_at").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, field := range []string{"PublishAt", "Status"} {
+				if err := tx.Migrator().DropIndex(&Post{}, field); err != nil {
+					return err
+				}
+				if err := dropColumn(tx, &Post{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// createPostRevisions 创建 post_revisions，并把已有文章的当前内容记录为第 1 版
+func createPostRevisions() Migration {
+	type Post struct {
+		ID uint `gorm:"primaryKey"`
+	}
+	type PostRevision struct {
+		ID           uint   `gorm:"primaryKey"`
+		PostID       uint   `gorm:"not null;uniqueIndex:idx_post_revisions_post_version"`
+		Post         Post   `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+		Version      int    `gorm:"not null;uniqueIndex:idx_post_revisions_post_version"`
+		Title        string `gorm:"type:varchar(200);not null"`
+		Content      string `gorm:"type:text;not null"`
+		EditorID     uint   `gorm:"not null"`
+		RestoredFrom *int
+		CreatedAt    time.Time
+	}
+
+	return Migration{
+		Version: 9,
+		Name:    "create_post_revisions",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&PostRevision{}); err != nil {
+				return err
+			}
+			return tx.Exec(`INSERT INTO post_revisions (post_id, version, title, content, editor_id, created_at)
+				SELECT id, 1, title, content, user_id, updated_at FROM posts`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&PostRevision{})
+		},
+	}
+}
+
+// addRenderedContent 为 posts 增加 content_html 和 excerpt，为 comments 增加 content_html，
+// 并渲染已有的文章和评论（包括已软删除的）
+func addRenderedContent() Migration {
+	type Post struct {
+		ID          uint   `gorm:"primaryKey"`
+		Content     string `gorm:"type:text;not null"`
+		ContentHTML string `gorm:"type:text"`
+		Excerpt     string `gorm:"type:varchar(300)"`
+	}
+	type Comment struct {
+		ID          uint   `gorm:"primaryKey"`
+		Content     string `gorm:"type:text;not null"`
+		ContentHTML string `gorm:"type:text"`
+	}
+
+	return Migration{
+		Version: 10,
+		Name:    "add_rendered_content",
+		Up: func(tx *gorm.DB) error {
+			for _, field := range []string{"ContentHTML", "Excerpt"} {
+				if err := tx.Migrator().AddColumn(&Post{}, field); err != nil {
+					return err
+				}
+			}
+			if err := tx.Migrator().AddColumn(&Comment{}, "ContentHTML"); err != nil {
+				return err
+			}
+
+			var posts []Post
+			err := tx.Select("id", "content").FindInBatches(&posts, 200, func(_ *gorm.DB, _ int) error {
+				for _, p := range posts {
+					rendered := markdown.Render(p.Content)
+					err := tx.Model(&Post{}).Where("id = ?", p.ID).Updates(map[string]interface{}{
+						"content_html": rendered,
+						"excerpt":      markdown.Excerpt(rendered, markdown.DefaultExcerptLength),
+					}).Error
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+			if err != nil {
+				return err
+			}
+
+			var comments []Comment
+			return tx.Select("id", "content").FindInBatches(&comments, 200, func(_ *gorm.DB, _ int) error {
+				for _, c := range comments {
+					err := tx.Model(&Comment{}).Where("id = ?", c.ID).Update("content_html", markdown.Render(c.Content)).Error
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := dropColumn(tx, &Comment{}, "ContentHTML"); err != nil {
+				return err
+			}
+			for _, field := range []string{"Excerpt", "ContentHTML"} {
+				if err := dropColumn(tx, &Post{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// addPostSlugs 为 posts 增加唯一的 slug，创建记录历史 slug 的 post_slugs，
+// 并按 ID 顺序为已有文章（包括已软删除的）生成 slug
+func addPostSlugs() Migration {
+	type Post struct {
+		ID    uint   `gorm:"primaryKey"`
+		Title string `gorm:"type:varchar(200);not null"`
+		Slug  string `gorm:"type:varchar(100);uniqueIndex"`
+	}
+	type PostSlug struct {
+		ID        uint   `gorm:"primaryKey"`
+		PostID    uint   `gorm:"not null;index"`
+		Post      Post   `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+		Slug      string `gorm:"type:varchar(100);uniqueIndex;not null"`
+		CreatedAt time.Time
+	}
+
+	return Migration{
+		Version: 11,
+		Name:    "add_post_slugs",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&Post{}, "Slug"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().CreateTable(&PostSlug{}); err != nil {
+				return err
+			}
+
+			used := make(map[string]bool)
+			var posts []Post
+			err := tx.Select("id", "title").Order("id").FindInBatches(&posts, 200, func(_ *gorm.DB, _ int) error {
+				for _, p := range posts {
+					s, err := slug.Unique(slug.Make(p.Title), func(s string) (bool, error) { return used[s], nil })
+					if err != nil {
+						return err
+					}
+					used[s] = true
+					if err := tx.Model(&Post{}).Where("id = ?", p.ID).Update("slug", s).Error; err != nil {
+						return err
+					}
+					if err := tx.Create(&PostSlug{PostID: p.ID, Slug: s}).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&Post{}, "Slug")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&PostSlug{}); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropIndex(&Post{}, "Slug"); err != nil {
+				return err
+			}
+			return dropColumn(tx, &Post{}, "Slug")
+		},
+	}
+}
+
+// createAttachments 创建 attachments 表，删除文章时附件保留并解除关联
+func createAttachments() Migration {
+	type User struct {
+		ID uint `gorm:"primaryKey"`
+	}
+	type Post struct {
+		ID uint `gorm:"primaryKey"`
+	}
+	type Attachment struct {
+		ID           uint   `gorm:"primaryKey"`
+		UserID       uint   `gorm:"not null;index"`
+		User         User   `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+		PostID       *uint  `gorm:"index"`
+		Post         *Post  `gorm:"foreignKey:PostID;constraint:OnDelete:SET NULL"`
+		Filename     string `gorm:"type:varchar(255);not null"`
+		ContentType  string `gorm:"type:varchar(100);not null"`
+		Size         int64  `gorm:"not null"`
+		StorageKey   string `gorm:"type:varchar(255);uniqueIndex;not null"`
+		URL          string `gorm:"type:varchar(500);not null"`
+		ThumbnailKey string `gorm:"type:varchar(255)"`
+		ThumbnailURL string `gorm:"type:varchar(500)"`
+		Width        int
+		Height       int
+		CreatedAt    time.Time
+	}
+
+	return Migration{
+		Version: 12,
+		Name:    "create_attachments",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&Attachment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&Attachment{})
+		},
+	}
+}
+
+// addLikesAndBookmarks 创建 likes、bookmarks 表，为 posts 增加点赞数和浏览量
+func addLikesAndBookmarks() Migration {
+	type User struct {
+		ID uint `gorm:"primaryKey"`
+	}
+	type Post struct {
+		ID        uint  `gorm:"primaryKey"`
+		LikeCount int64 `gorm:"not null;default:0"`
+		ViewCount int64 `gorm:"not null;default:0"`
+	}
+	type Like struct {
+		UserID    uint `gorm:"primaryKey"`
+		User      User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+		PostID    uint `gorm:"primaryKey;index"`
+		Post      Post `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+		CreatedAt time.Time
+	}
+	type Bookmark struct {
+		UserID    uint `gorm:"primaryKey"`
+		User      User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+		PostID    uint `gorm:"primaryKey;index"`
+		Post      Post `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+		CreatedAt time.Time
+	}
+
+	return Migration{
+		Version: 13,
+		Name:    "add_likes_and_bookmarks",
+		Up: func(tx *gorm.DB) error {
+			for _, field := range []string{"LikeCount", "ViewCount"} {
+				if err := tx.Migrator().AddColumn(&Post{}, field); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().CreateTable(&Like{}, &Bookmark{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&Bookmark{}, &Like{}); err != nil {
+				return err
+			}
+			for _, field := range []string{"ViewCount", "LikeCount"} {
+				if err := dropColumn(tx, &Post{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// addCounters 为 users 增加文章数，把 posts 的评论数从查询时的子查询改为实际的列，并根据已有数据回填
+func addCounters() Migration {
+	type User struct {
+		ID        uint  `gorm:"primaryKey"`
+		PostCount int64 `gorm:"not null;default:0"`
+	}
+	type Post struct {
+		ID           uint  `gorm:"primaryKey"`
+		CommentCount int64 `gorm:"not null;default:0;index"`
+	}
+
+	return Migration{
+		Version: 14,
+		Name:    "add_counters",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&User{}, "PostCount"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().AddColumn(&Post{}, "CommentCount"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().CreateIndex(&Post{}, "CommentCount"); err != nil {
+				return err
+			}
+
+			err := tx.Exec(`UPDATE users SET post_count =
+				(SELECT COUNT(*) FROM posts WHERE posts.user_id = users.id AND posts.deleted_at IS NULL)`).Error
+			if err != nil {
+				return err
+			}
+			return tx.Exec(`UPDATE posts SET comment_count =
+				(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL)`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&Post{}, "CommentCount"); err != nil {
+				return err
+			}
+			if err := dropColumn(tx, &Post{}, "CommentCount"); err != nil {
+				return err
+			}
+			return dropColumn(tx, &User{}, "PostCount")
+		},
+	}
+}
+
+// addUserProfiles 为 users 增加显示名称、简介和头像
+func addUserProfiles() Migration {
+	type User struct {
+		ID          uint   `gorm:"primaryKey"`
+		DisplayName string `gorm:"type:varchar(50)"`
+		Bio         string `gorm:"type:varchar(500)"`
+		AvatarURL   string `gorm:"type:varchar(255)"`
+	}
+	fields := []string{"DisplayName", "Bio", "AvatarURL"}
+
+	return Migration{
+		Version: 15,
+		Name:    "add_user_profiles",
+		Up: func(tx *gorm.DB) error {
+			for _, field := range fields {
+				if err := tx.Migrator().AddColumn(&User{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, field := range fields {
+				if err := dropColumn(tx, &User{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// addEmailVerification 为 users 增加邮箱验证时间
+func addEmailVerification() Migration {
+	type User struct {
+		ID              uint `gorm:"primaryKey"`
+		EmailVerifiedAt *time.Time
+	}
+
+	return Migration{
+		Version: 16,
+		Name:    "add_email_verification",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&User{}, "EmailVerifiedAt")
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumn(tx, &User{}, "EmailVerifiedAt")
+		},
+	}
+}
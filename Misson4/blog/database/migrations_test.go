@@ -0,0 +1,108 @@
+package database
+
+import (
+	"blog/config"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestMigrationsAreComplete(t *testing.T) {
+	list, err := sortedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range list {
+		// 版本号从 1 开始连续递增，迁移按追加顺序编号
+		if m.Version != i+1 {
+			t.Errorf("migration %q has version %d, want %d", m.Name, m.Version, i+1)
+		}
+		if m.Name == "" || m.Up == nil || m.Down == nil {
+			t.Errorf("migration %d must have a name, Up and Down", m.Version)
+		}
+	}
+}
+
+func TestSortedMigrations(t *testing.T) {
+	saved := migrations
+	t.Cleanup(func() { migrations = saved })
+
+	tests := []struct {
+		name     string
+		versions []int
+		want     []int
+		wantErr  string
+	}{
+		{"already sorted", []int{1, 2, 3}, []int{1, 2, 3}, ""},
+		{"out of order", []int{3, 1, 2}, []int{1, 2, 3}, ""},
+		{"duplicate version", []int{1, 2, 2}, nil, "duplicate migration version 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations = nil
+			for _, v := range tt.versions {
+				migrations = append(migrations, Migration{Version: v})
+			}
+			list, err := sortedMigrations()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("sortedMigrations() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, m := range list {
+				if m.Version != tt.want[i] {
+					t.Fatalf("version at %d = %d, want %d", i, m.Version, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDropColumnKeepsIndexesAndChildRows(t *testing.T) {
+	type Parent struct {
+		ID   uint   `gorm:"primaryKey"`
+		Code string `gorm:"type:varchar(20);uniqueIndex"`
+		Note string `gorm:"type:varchar(20)"`
+	}
+	type Child struct {
+		ID       uint   `gorm:"primaryKey"`
+		ParentID uint   `gorm:"not null;index"`
+		Parent   Parent `gorm:"constraint:OnDelete:CASCADE"`
+	}
+
+	db, err := Open(config.DatabaseConfig{Driver: config.DriverSQLite, DSN: ":memory:"}, config.LogLevelError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.AutoMigrate(&Parent{}, &Child{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&Child{Parent: Parent{Code: "a", Note: "x"}}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	err = runMigration(db, func(tx *gorm.DB) error { return dropColumn(tx, &Parent{}, "Note") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasColumn(&Parent{}, "Note") {
+		t.Error("column was not dropped")
+	}
+	if !db.Migrator().HasIndex(&Parent{}, "Code") {
+		t.Error("index on another column was lost")
+	}
+	var children int64
+	if err := db.Model(&Child{}).Count(&children).Error; err != nil || children != 1 {
+		t.Errorf("child rows = %d, %v; want 1", children, err)
+	}
+}
@@ -0,0 +1,141 @@
+// Package diff 计算两段文本之间按行的差异，用于比较文章的历史版本。
+package diff
+
+import "strings"
+
+// 差异操作
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// maxEdits 编辑距离上限。超过时不再寻找最短编辑序列，直接把旧文本整体替换为新文本，
+// 避免两段完全不同的长文本消耗过多内存。
+const maxEdits = 1000
+
+// Line 差异中的一行。OldLine、NewLine 为该行在旧、新文本中的行号（从 1 开始），
+// 新增的行没有 OldLine，删除的行没有 NewLine。
+type Line struct {
+	Op      string `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// Lines 使用 Myers 算法计算从 a 到 b 的最短按行编辑序列
+func Lines(a, b string) []Line {
+	x, y := splitLines(a), splitLines(b)
+	n, m := len(x), len(y)
+
+	// v[k] 为对角线 k 上目前能到达的最远 x 坐标；trace[d] 保存第 d 步开始前 v 在 [-d, d] 上的值
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		if d > maxEdits {
+			return replaceAll(x, y)
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+
+		for k := -d; k <= d; k += 2 {
+			var i int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				i = v[offset+k+1]
+			} else {
+				i = v[offset+k-1] + 1
+			}
+			j := i - k
+			for i < n && j < m && x[i] == y[j] {
+				i++
+				j++
+			}
+			v[offset+k] = i
+
+			if i >= n && j >= m {
+				return backtrack(trace, x, y)
+			}
+		}
+	}
+	return replaceAll(x, y)
+}
+
+// backtrack 从终点沿 trace 倒推出编辑序列
+func backtrack(trace [][]int, x, y []string) []Line {
+	var lines []Line
+	i, j := len(x), len(y)
+
+	for d := len(trace) - 1; d > 0; d-- {
+		// trace[d] 覆盖 [-d, d]，下标 k+d
+		v := trace[d]
+		k := i - j
+
+		var prevK int
+		if k == -d || (k != d && v[k-1+d] < v[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevI := v[prevK+d]
+		prevJ := prevI - prevK
+
+		for i > prevI && j > prevJ {
+			lines = append(lines, Line{Op: OpEqual, Text: x[i-1], OldLine: i, NewLine: j})
+			i--
+			j--
+		}
+		if i == prevI {
+			lines = append(lines, Line{Op: OpInsert, Text: y[j-1], NewLine: j})
+		} else {
+			lines = append(lines, Line{Op: OpDelete, Text: x[i-1], OldLine: i})
+		}
+		i, j = prevI, prevJ
+	}
+	for i > 0 && j > 0 {
+		lines = append(lines, Line{Op: OpEqual, Text: x[i-1], OldLine: i, NewLine: j})
+		i--
+		j--
+	}
+
+	for l, r := 0, len(lines)-1; l < r; l, r = l+1, r-1 {
+		lines[l], lines[r] = lines[r], lines[l]
+	}
+	return lines
+}
+
+// replaceAll 删除 x 的全部行并插入 y 的全部行
+func replaceAll(x, y []string) []Line {
+	lines := make([]Line, 0, len(x)+len(y))
+	for i, text := range x {
+		lines = append(lines, Line{Op: OpDelete, Text: text, OldLine: i + 1})
+	}
+	for j, text := range y {
+		lines = append(lines, Line{Op: OpInsert, Text: text, NewLine: j + 1})
+	}
+	return lines
+}
+
+// splitLines 按行切分文本，统一换行符并忽略末尾的换行，空文本没有任何行
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// Stats 统计差异中新增和删除的行数
+func Stats(lines []Line) (added, removed int) {
+	for _, line := range lines {
+		switch line.Op {
+		case OpInsert:
+			added++
+		case OpDelete:
+			removed++
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,99 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+// render 把差异写成 " a"、"+b"、"-c" 形式的行，便于比较
+func render(lines []Line) string {
+	var b strings.Builder
+	for _, l := range lines {
+		switch l.Op {
+		case OpEqual:
+			b.WriteByte(' ')
+		case OpInsert:
+			b.WriteByte('+')
+		case OpDelete:
+			b.WriteByte('-')
+		}
+		b.WriteString(l.Text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"both empty", "", "", ""},
+		{"equal", "a\nb", "a\nb", " a\n b\n"},
+		{"insert into empty", "", "a\nb", "+a\n+b\n"},
+		{"delete all", "a\nb", "", "-a\n-b\n"},
+		{"insert in middle", "a\nc", "a\nb\nc", " a\n+b\n c\n"},
+		{"delete in middle", "a\nb\nc", "a\nc", " a\n-b\n c\n"},
+		{"replace line", "a\nb\nc", "a\nx\nc", " a\n-b\n+x\n c\n"},
+		{"trailing newline", "a\n", "a\nb\n", " a\n+b\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := render(Lines(tt.a, tt.b)); got != tt.want {
+				t.Errorf("Lines(%q, %q) =\n%s\nwant\n%s", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinesNumbers(t *testing.T) {
+	got := Lines("a\nb\nc", "a\nx\nc")
+	want := []Line{
+		{Op: OpEqual, Text: "a", OldLine: 1, NewLine: 1},
+		{Op: OpDelete, Text: "b", OldLine: 2},
+		{Op: OpInsert, Text: "x", NewLine: 2},
+		{Op: OpEqual, Text: "c", OldLine: 3, NewLine: 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Lines() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLinesTooManyEdits(t *testing.T) {
+	var a, b []string
+	for i := 0; i < maxEdits; i++ {
+		a = append(a, "old")
+		b = append(b, "new")
+	}
+	added, removed := Stats(Lines(strings.Join(a, "\n"), strings.Join(b, "\n")))
+	if added != maxEdits || removed != maxEdits {
+		t.Errorf("Stats() = +%d -%d, want +%d -%d", added, removed, maxEdits, maxEdits)
+	}
+}
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		a, b           string
+		added, removed int
+	}{
+		{"no change", "a\nb", "a\nb", 0, 0},
+		{"one added", "a", "a\nb", 1, 0},
+		{"one replaced", "a\nb", "a\nc", 1, 1},
+		{"all removed", "a\nb\nc", "", 0, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := Stats(Lines(tt.a, tt.b))
+			if added != tt.added || removed != tt.removed {
+				t.Errorf("Stats() = +%d -%d, want +%d -%d", added, removed, tt.added, tt.removed)
+			}
+		})
+	}
+}
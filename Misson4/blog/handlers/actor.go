@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"blog/apierror"
+	"blog/logging"
+	"blog/middleware"
+	"blog/response"
+	"blog/service"
+	"errors"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// actorFrom 从认证中间件写入的上下文构造当前操作者，OptionalAuth 之后未登录时 ID 为 0
+func actorFrom(c *gin.Context) service.Actor {
+	userID, _ := middleware.GetUserID(c)
+	return service.Actor{
+		ID:   userID,
+		Role: middleware.GetRole(c),
+	}
+}
+
+// respondViewerTokenInvalid 公开接口找不到资源时，如果请求带了无效的令牌，
+// 可能是令牌过期导致看不到自己的草稿，写入 401 让客户端刷新令牌后重试并返回 true
+func respondViewerTokenInvalid(c *gin.Context) bool {
+	if _, err := middleware.GetUserID(c); !errors.Is(err, middleware.ErrInvalidToken) {
+		return false
+	}
+	response.Error(c, apierror.InvalidToken("Invalid or expired token"))
+	return true
+}
+
+// requestLogger 返回请求的 logger，日志带有请求 ID 和当前用户 ID
+func requestLogger(c *gin.Context) *slog.Logger {
+	return logging.FromContext(c.Request.Context())
+}
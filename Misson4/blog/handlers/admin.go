@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"blog/apierror"
+	"blog/models"
+	"blog/response"
+	"blog/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListUsersQuery 用户列表查询参数
+type ListUsersQuery struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// UpdateRoleRequest 修改用户角色请求结构
+type UpdateRoleRequest struct {
+	Role models.Role `json:"role" binding:"required,oneof=reader author editor admin"`
+}
+
+// AdminHandler 管理员接口
+type AdminHandler struct {
+	users *service.UserService
+}
+
+// NewAdminHandler 创建 AdminHandler
+func NewAdminHandler(users *service.UserService) *AdminHandler {
+	return &AdminHandler{users: users}
+}
+
+// ListUsers 分页获取用户列表
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	var query ListUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	users, total, err := h.users.List(c.Request.Context(), query.Page, query.PageSize)
+	if err != nil {
+		response.Error(c, apierror.Internal("Failed to fetch users", err))
+		return
+	}
+
+	accounts := make([]account, len(users))
+	for i := range users {
+		accounts[i] = accountOf(&users[i])
+	}
+	response.List(c, accounts, gin.H{"total": total})
+}
+
+// UpdateUserRole 修改用户角色
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	userID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid user ID"))
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	user, err := h.users.SetRole(c.Request.Context(), actorFrom(c), userID, req.Role)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		response.Error(c, apierror.NotFound("User not found"))
+		return
+	case errors.Is(err, service.ErrOwnRole):
+		response.Error(c, apierror.BadRequest("You cannot change your own role"))
+		return
+	case errors.Is(err, service.ErrInvalidRole):
+		response.Error(c, apierror.Invalid("role", "oneof", "Invalid role"))
+		return
+	case err != nil:
+		response.Error(c, apierror.Internal("Failed to update role", err))
+		return
+	}
+
+	requestLogger(c).Info("user role updated", "target_user_id", user.ID, "role", user.Role)
+	response.OK(c, accountOf(user))
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"blog/apierror"
+	"blog/response"
+	"blog/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead 上传请求体中文件以外部分（边界、表单字段）的预留大小
+const multipartOverhead = 1 << 20
+
+// LinkAttachmentRequest 关联附件到文章请求结构
+type LinkAttachmentRequest struct {
+	PostID uint `json:"post_id" binding:"required"`
+}
+
+// AttachmentHandler 图片和附件接口
+type AttachmentHandler struct {
+	attachments *service.AttachmentService
+}
+
+// NewAttachmentHandler 创建 AttachmentHandler
+func NewAttachmentHandler(attachments *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{attachments: attachments}
+}
+
+// Upload 上传文件（multipart 表单字段 file），可以用表单字段 post_id 同时关联到文章
+func (h *AttachmentHandler) Upload(c *gin.Context) {
+	var postID *uint
+	if v := c.PostForm("post_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 0)
+		if err != nil || id == 0 {
+			response.Error(c, apierror.BadRequest("Invalid post ID"))
+			return
+		}
+		postID = new(uint)
+		*postID = uint(id)
+	}
+	h.upload(c, postID)
+}
+
+// UploadToPost 上传文件并关联到路径中的文章
+func (h *AttachmentHandler) UploadToPost(c *gin.Context) {
+	postID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid post ID"))
+		return
+	}
+	h.upload(c, &postID)
+}
+
+// upload 读取 multipart 表单中的 file 并保存
+func (h *AttachmentHandler) upload(c *gin.Context, postID *uint) {
+	actor := actorFrom(c)
+	if actor.ID == 0 {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
+		return
+	}
+
+	// 限制整个请求体的大小，超出时解析表单会失败，不会把超大文件写入临时目录
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.attachments.MaxSize()+multipartOverhead)
+	header, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || (err == nil && header.Size > h.attachments.MaxSize()) {
+		response.Error(c, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "File too large"))
+		return
+	}
+	if err != nil {
+		response.Error(c, apierror.Invalid("file", "required", "Missing file field"))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		response.Error(c, apierror.Internal("Failed to read file", err))
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.attachments.Upload(c.Request.Context(), actor, service.UploadInput{
+		Filename: header.Filename,
+		Body:     file,
+		PostID:   postID,
+	})
+	if respondAttachmentError(c, err, "Failed to upload file") {
+		return
+	}
+
+	requestLogger(c).Info("file uploaded", "attachment_id", attachment.ID, "size", attachment.Size)
+	response.Created(c, attachment)
+}
+
+// ListPostAttachments 获取已发布文章的附件
+func (h *AttachmentHandler) ListPostAttachments(c *gin.Context) {
+	postID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid post ID"))
+		return
+	}
+
+	attachments, err := h.attachments.ListByPost(c.Request.Context(), postID)
+	if respondAttachmentError(c, err, "Failed to fetch attachments") {
+		return
+	}
+
+	response.List(c, attachments, nil)
+}
+
+// LinkAttachment 把已上传的附件关联到文章
+func (h *AttachmentHandler) LinkAttachment(c *gin.Context) {
+	actor := actorFrom(c)
+	if actor.ID == 0 {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
+		return
+	}
+	id, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid attachment ID"))
+		return
+	}
+
+	var req LinkAttachmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	attachment, err := h.attachments.Link(c.Request.Context(), actor, id, req.PostID)
+	if respondAttachmentError(c, err, "Failed to update attachment") {
+		return
+	}
+
+	response.OK(c, attachment)
+}
+
+// DeleteAttachment 删除附件及其文件
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	actor := actorFrom(c)
+	if actor.ID == 0 {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
+		return
+	}
+	id, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid attachment ID"))
+		return
+	}
+
+	err := h.attachments.Delete(c.Request.Context(), actor, id)
+	if respondAttachmentError(c, err, "Failed to delete attachment") {
+		return
+	}
+
+	requestLogger(c).Info("attachment deleted", "attachment_id", id)
+	response.NoContent(c)
+}
+
+// respondAttachmentError 把附件接口的错误写成响应，其他错误按 message 作为内部错误输出。
+// err 为空时返回 false，否则返回 true
+func respondAttachmentError(c *gin.Context, err error, message string) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, service.ErrAttachmentNotFound):
+		response.Error(c, apierror.NotFound("Attachment not found"))
+	case errors.Is(err, service.ErrPostNotFound):
+		response.Error(c, apierror.NotFound("Post not found"))
+	case errors.Is(err, service.ErrForbidden):
+		response.Error(c, apierror.Forbidden("Forbidden"))
+	case errors.Is(err, service.ErrFileTooLarge):
+		response.Error(c, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "File too large"))
+	case errors.Is(err, service.ErrUnsupportedFile):
+		response.Error(c, apierror.New(http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia, "Unsupported file type, allowed: JPEG, PNG, GIF, WebP, PDF, plain text"))
+	default:
+		response.Error(c, apierror.Internal(message, err))
+	}
+	return true
+}
@@ -1,14 +1,16 @@
 package handlers
 
 import (
-	"blog/database"
+	"blog/apierror"
 	"blog/middleware"
-	"blog/models"
-	"log"
+	"blog/ratelimit"
+	"blog/response"
+	"blog/service"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // RegisterRequest 注册请求结构
@@ -24,95 +26,204 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-// Register 用户注册
-func Register(c *gin.Context) {
+// RefreshRequest 刷新令牌请求结构
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest 注销请求结构，提供刷新令牌时同时作废该登录会话的所有刷新令牌
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// AuthHandler 用户注册、登录和令牌管理接口
+type AuthHandler struct {
+	users   *service.UserService
+	tokens  *service.TokenService
+	emails  *service.EmailService
+	lockout *ratelimit.Lockout
+}
+
+// NewAuthHandler 创建 AuthHandler，注册成功后通过 emails 发送验证邮件，
+// 登录失败次数过多时由 lockout 锁定账号
+func NewAuthHandler(users *service.UserService, tokens *service.TokenService, emails *service.EmailService, lockout *ratelimit.Lockout) *AuthHandler {
+	return &AuthHandler{users: users, tokens: tokens, emails: emails, lockout: lockout}
+}
+
+// Register 用户注册，注册成功后发送验证邮件
+func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		log.Printf("Register validation error: %v", err)
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	user, err := h.users.Register(c.Request.Context(), service.RegisterInput{
+		Username: req.Username,
+		Password: req.Password,
+		Email:    req.Email,
+	})
+	switch {
+	case errors.Is(err, service.ErrUsernameTaken):
+		response.Error(c, apierror.Conflict(apierror.CodeUsernameTaken, "Username already exists"))
+		requestLogger(c).Info("register failed: username taken", "username", req.Username)
+		return
+	case errors.Is(err, service.ErrEmailTaken):
+		response.Error(c, apierror.Conflict(apierror.CodeEmailTaken, "Email already exists"))
+		requestLogger(c).Info("register failed: email taken")
+		return
+	case err != nil:
+		response.Error(c, apierror.Internal("Failed to create user", err))
+		return
+	}
+
+	// 验证邮件发送失败不影响注册，用户可以登录后重新发送
+	if err := h.emails.SendVerification(c.Request.Context(), user); err != nil {
+		requestLogger(c).Error("send verification email failed", "user_id", user.ID, "error", err)
+	}
+
+	requestLogger(c).Info("user registered", "user_id", user.ID, "username", req.Username)
+	response.Created(c, gin.H{
+		"user": gin.H{
+			"id":             user.ID,
+			"username":       user.Username,
+			"email":          user.Email,
+			"role":           user.Role,
+			"email_verified": false,
+		},
+	})
+}
+
+// Login 用户登录。同一账号连续登录失败后会被逐步延长锁定，锁定期间返回 429 和 Retry-After
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
 		return
 	}
 
-	// 检查用户名是否已存在
-	var existingUser models.User
-	if err := database.DB.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
-		log.Printf("Register failed: username %s already exists", req.Username)
+	// 锁定期间不校验密码，锁定存储出错时放行
+	ctx := c.Request.Context()
+	locked, err := h.lockout.Locked(ctx, req.Username)
+	if err != nil {
+		requestLogger(c).Error("login lockout store error", "error", err)
+	}
+	if locked > 0 {
+		respondLocked(c, locked)
 		return
 	}
 
-	// 加密密码
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	user, err := h.users.Authenticate(ctx, req.Username, req.Password)
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		requestLogger(c).Warn("login failed: invalid credentials", "username", req.Username)
+		locked, err := h.lockout.Fail(ctx, req.Username)
+		if err != nil {
+			requestLogger(c).Error("login lockout store error", "error", err)
+		}
+		if locked > 0 {
+			requestLogger(c).Warn("login locked", "username", req.Username, "duration", locked)
+			respondLocked(c, locked)
+			return
+		}
+		response.Error(c, apierror.New(http.StatusUnauthorized, apierror.CodeInvalidCredentials, "Invalid username or password"))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
-		log.Printf("Password hashing error: %v", err)
+		response.Error(c, apierror.Internal("Failed to log in", err))
 		return
 	}
 
-	// 创建用户
-	user := models.User{
-		Username: req.Username,
-		Password: string(hashedPassword),
-		Email:    req.Email,
+	if err := h.lockout.Succeed(ctx, req.Username); err != nil {
+		requestLogger(c).Error("login lockout store error", "error", err)
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
-		log.Printf("User creation error: %v", err)
+	// 签发访问令牌和刷新令牌
+	pair, err := h.tokens.Issue(ctx, user)
+	if err != nil {
+		response.Error(c, apierror.Internal("Failed to generate token", err))
 		return
 	}
 
-	log.Printf("User registered successfully: %s", req.Username)
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "User registered successfully",
+	requestLogger(c).Info("user logged in", "user_id", user.ID, "username", req.Username)
+	response.OK(c, gin.H{
+		"token":                    pair.AccessToken,
+		"expires_at":               pair.AccessTokenExpiresAt,
+		"refresh_token":            pair.RefreshToken,
+		"refresh_token_expires_at": pair.RefreshTokenExpiresAt,
 		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
+			"id":             user.ID,
+			"username":       user.Username,
+			"email":          user.Email,
+			"role":           user.Role,
+			"email_verified": user.EmailVerifiedAt != nil,
 		},
 	})
 }
 
-// Login 用户登录
-func Login(c *gin.Context) {
-	var req LoginRequest
+// respondLocked 账号因登录失败次数过多被锁定时的响应
+func respondLocked(c *gin.Context, d time.Duration) {
+	middleware.SetRetryAfter(c, d)
+	response.Error(c, apierror.New(http.StatusTooManyRequests, apierror.CodeAccountLocked, "Too many failed login attempts, please try again later"))
+}
+
+// Refresh 用刷新令牌换取新的访问令牌和刷新令牌，旧刷新令牌随即作废
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		log.Printf("Login validation error: %v", err)
+		response.Error(c, apierror.FromBinding(err))
 		return
 	}
 
-	// 查找用户
-	var user models.User
-	if err := database.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
-		log.Printf("Login failed: user %s not found", req.Username)
+	pair, user, err := h.tokens.Refresh(c.Request.Context(), req.RefreshToken)
+	switch {
+	case errors.Is(err, service.ErrTokenReused):
+		requestLogger(c).Warn("revoked refresh token reused, token family revoked")
+		response.Error(c, invalidRefreshToken())
+		return
+	case errors.Is(err, service.ErrInvalidToken):
+		response.Error(c, invalidRefreshToken())
+		return
+	case err != nil:
+		response.Error(c, apierror.Internal("Failed to refresh token", err))
 		return
 	}
 
-	// 验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
-		log.Printf("Login failed: invalid password for user %s", req.Username)
+	requestLogger(c).Info("token refreshed", "user_id", user.ID)
+	response.OK(c, gin.H{
+		"token":                    pair.AccessToken,
+		"expires_at":               pair.AccessTokenExpiresAt,
+		"refresh_token":            pair.RefreshToken,
+		"refresh_token_expires_at": pair.RefreshTokenExpiresAt,
+	})
+}
+
+// Logout 注销当前访问令牌，并作废请求中刷新令牌所在的登录会话
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
 		return
 	}
 
-	// 生成 JWT token
-	token, err := middleware.GenerateToken(&user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
-		log.Printf("Token generation error: %v", err)
+	var req LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Error(c, apierror.FromBinding(err))
+			return
+		}
+	}
+
+	jti, expiresAt := middleware.GetTokenInfo(c)
+	if err := h.tokens.Logout(c.Request.Context(), userID, req.RefreshToken, jti, expiresAt); err != nil {
+		response.Error(c, apierror.Internal("Failed to log out", err))
 		return
 	}
 
-	log.Printf("User logged in successfully: %s", req.Username)
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"token":   token,
-		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-		},
-	})
+	requestLogger(c).Info("user logged out")
+	response.NoContent(c)
+}
+
+// invalidRefreshToken 刷新令牌无效或已被使用时的错误
+func invalidRefreshToken() *apierror.Error {
+	return apierror.New(http.StatusUnauthorized, apierror.CodeInvalidRefreshToken, "Invalid or expired refresh token")
 }
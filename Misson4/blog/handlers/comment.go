@@ -1,88 +1,195 @@
 package handlers
 
 import (
-	"blog/database"
+	"blog/apierror"
 	"blog/middleware"
-	"blog/models"
-	"log"
-	"net/http"
+	"blog/response"
+	"blog/service"
+	"errors"
 
 	"github.com/gin-gonic/gin"
 )
 
 // CreateCommentRequest 创建评论请求结构
 type CreateCommentRequest struct {
+	Content  string `json:"content" binding:"required"`
+	ParentID *uint  `json:"parent_id"` // 回复某条评论时填写
+}
+
+// UpdateCommentRequest 修改评论请求结构
+type UpdateCommentRequest struct {
 	Content string `json:"content" binding:"required"`
 }
 
+// ListCommentsQuery 评论列表查询参数
+type ListCommentsQuery struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	// Format 为 tree（默认）时返回嵌套的 replies，为 flat 时按先序展开并用 depth 表示层级
+	Format string `form:"format" binding:"omitempty,oneof=tree flat"`
+}
+
+// CommentHandler 评论接口
+type CommentHandler struct {
+	comments *service.CommentService
+}
+
+// NewCommentHandler 创建 CommentHandler
+func NewCommentHandler(comments *service.CommentService) *CommentHandler {
+	return &CommentHandler{comments: comments}
+}
+
 // CreateComment 创建评论
-func CreateComment(c *gin.Context) {
-	userID := middleware.GetUserID(c)
-	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
 		return
 	}
 
-	postID := c.Param("id")
-
-	// 检查文章是否存在
-	var post models.Post
-	if err := database.DB.First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
-		log.Printf("CreateComment error: post ID %s not found", postID)
+	postID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid post ID"))
 		return
 	}
 
 	var req CreateCommentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		log.Printf("CreateComment validation error: %v", err)
+		response.Error(c, apierror.FromBinding(err))
 		return
 	}
 
-	comment := models.Comment{
-		Content: req.Content,
-		UserID:  userID,
-		PostID:  post.ID,
+	comment, err := h.comments.Create(c.Request.Context(), userID, postID, service.CreateCommentInput{
+		Content:  req.Content,
+		ParentID: req.ParentID,
+	})
+	switch {
+	case errors.Is(err, service.ErrPostNotFound):
+		response.Error(c, apierror.NotFound("Post not found"))
+		requestLogger(c).Debug("post not found", "post_id", postID)
+		return
+	case errors.Is(err, service.ErrInvalidParent):
+		response.Error(c, apierror.Invalid("parent_id", "exists", "Parent comment not found in this post"))
+		requestLogger(c).Debug("parent comment not found", "post_id", postID, "parent_id", *req.ParentID)
+		return
+	case err != nil:
+		response.Error(c, apierror.Internal("Failed to create comment", err))
+		return
+	}
+
+	requestLogger(c).Info("comment created", "comment_id", comment.ID, "post_id", postID)
+	response.Created(c, comment)
+}
+
+// GetComments 分页获取文章的评论，顶级评论按时间倒序分页，每条带完整的回复
+func (h *CommentHandler) GetComments(c *gin.Context) {
+	postID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid post ID"))
+		return
 	}
 
-	if err := database.DB.Create(&comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
-		log.Printf("Comment creation error: %v", err)
+	var query ListCommentsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	page, err := h.comments.ListThreads(c.Request.Context(), postID, query.Cursor, query.Limit)
+	switch {
+	case errors.Is(err, service.ErrPostNotFound):
+		response.Error(c, apierror.NotFound("Post not found"))
+		requestLogger(c).Debug("post not found", "post_id", postID)
+		return
+	case errors.Is(err, service.ErrInvalidCursor):
+		response.Error(c, apierror.Invalid("cursor", "cursor", "Invalid cursor"))
+		return
+	case err != nil:
+		response.Error(c, apierror.Internal("Failed to fetch comments", err))
 		return
 	}
 
-	// 加载用户信息
-	database.DB.Preload("User").First(&comment, comment.ID)
+	comments := page.Comments
+	if query.Format == "flat" {
+		comments = service.FlattenThreads(comments)
+	}
 
-	log.Printf("Comment created successfully: ID=%d, PostID=%d, UserID=%d", comment.ID, post.ID, userID)
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Comment created successfully",
-		"comment": comment,
+	response.List(c, comments, gin.H{
+		"limit":       page.Limit,
+		"total":       page.Total,
+		"has_more":    page.NextCursor != "",
+		"next_cursor": page.NextCursor,
 	})
 }
 
-// GetComments 获取文章的所有评论
-func GetComments(c *gin.Context) {
-	postID := c.Param("id")
+// UpdateComment 修改评论，只有评论作者可以修改
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	_, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
+		return
+	}
 
-	// 检查文章是否存在
-	var post models.Post
-	if err := database.DB.First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
-		log.Printf("GetComments error: post ID %s not found", postID)
+	commentID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid comment ID"))
 		return
 	}
 
-	var comments []models.Comment
-	if err := database.DB.Preload("User").Where("post_id = ?", postID).Order("created_at desc").Find(&comments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
-		log.Printf("GetComments error: %v", err)
+	var req UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"comments": comments,
-		"count":    len(comments),
-	})
+	comment, err := h.comments.Update(c.Request.Context(), actorFrom(c), commentID, req.Content)
+	switch {
+	case errors.Is(err, service.ErrCommentNotFound):
+		response.Error(c, apierror.NotFound("Comment not found"))
+		requestLogger(c).Debug("comment not found", "comment_id", commentID)
+		return
+	case errors.Is(err, service.ErrForbidden):
+		response.Error(c, apierror.Forbidden("You can only edit your own comments"))
+		requestLogger(c).Warn("edit comment denied: owned by another user", "comment_id", commentID)
+		return
+	case err != nil:
+		response.Error(c, apierror.Internal("Failed to update comment", err))
+		return
+	}
+
+	requestLogger(c).Info("comment updated", "comment_id", comment.ID)
+	response.OK(c, comment)
+}
+
+// DeleteComment 删除评论，评论作者和文章作者可以删除
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	_, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
+		return
+	}
+
+	commentID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid comment ID"))
+		return
+	}
+
+	err = h.comments.Delete(c.Request.Context(), actorFrom(c), commentID)
+	switch {
+	case errors.Is(err, service.ErrCommentNotFound):
+		response.Error(c, apierror.NotFound("Comment not found"))
+		requestLogger(c).Debug("comment not found", "comment_id", commentID)
+		return
+	case errors.Is(err, service.ErrForbidden):
+		response.Error(c, apierror.Forbidden("You can only delete your own comments or comments on your posts"))
+		requestLogger(c).Warn("delete comment denied", "comment_id", commentID)
+		return
+	case err != nil:
+		response.Error(c, apierror.Internal("Failed to delete comment", err))
+		return
+	}
+
+	requestLogger(c).Info("comment deleted", "comment_id", commentID)
+	response.NoContent(c)
 }
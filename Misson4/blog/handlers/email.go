@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"blog/apierror"
+	"blog/middleware"
+	"blog/ratelimit"
+	"blog/response"
+	"blog/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyEmailRequest 验证邮箱请求结构，token 来自验证邮件中的链接
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ForgotPasswordRequest 找回密码请求结构
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest 重置密码请求结构，token 来自重置密码邮件中的链接
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// EmailHandler 邮箱验证和找回密码接口
+type EmailHandler struct {
+	emails  *service.EmailService
+	lockout *ratelimit.Lockout
+}
+
+// NewEmailHandler 创建 EmailHandler，重置密码成功后解除 lockout 对该账号的登录锁定
+func NewEmailHandler(emails *service.EmailService, lockout *ratelimit.Lockout) *EmailHandler {
+	return &EmailHandler{emails: emails, lockout: lockout}
+}
+
+// VerifyEmail 校验邮件中的令牌并标记邮箱已验证
+func (h *EmailHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	user, err := h.emails.VerifyEmail(c.Request.Context(), req.Token)
+	if errors.Is(err, service.ErrInvalidEmailToken) {
+		response.Error(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidLink, "Invalid or expired verification link"))
+		return
+	}
+	if err != nil {
+		response.Error(c, apierror.Internal("Failed to verify email", err))
+		return
+	}
+
+	requestLogger(c).Info("email verified", "user_id", user.ID)
+	response.OK(c, gin.H{
+		"email_verified_at": user.EmailVerifiedAt,
+	})
+}
+
+// ResendVerification 重新向当前用户的邮箱发送验证邮件
+func (h *EmailHandler) ResendVerification(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
+		return
+	}
+
+	err = h.emails.ResendVerification(c.Request.Context(), userID)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		response.Error(c, apierror.NotFound("User not found"))
+		return
+	case errors.Is(err, service.ErrEmailVerified):
+		response.Error(c, apierror.Conflict(apierror.CodeEmailVerified, "Email already verified"))
+		return
+	case err != nil:
+		response.Error(c, apierror.Internal("Failed to send verification email", err))
+		return
+	}
+
+	response.Accepted(c)
+}
+
+// ForgotPassword 发送重置密码邮件。无论邮箱是否注册都返回相同的响应
+func (h *EmailHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	if err := h.emails.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		response.Error(c, apierror.Internal("Failed to send password reset email", err))
+		return
+	}
+
+	// 无论邮箱是否注册都返回 202，避免泄露哪些邮箱已注册
+	response.Accepted(c)
+}
+
+// ResetPassword 校验重置令牌后设置新密码，所有登录会话随之失效，并解除账号的登录锁定
+func (h *EmailHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.emails.ResetPassword(ctx, req.Token, req.NewPassword)
+	if errors.Is(err, service.ErrInvalidEmailToken) {
+		response.Error(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidLink, "Invalid or expired password reset link"))
+		return
+	}
+	if err != nil {
+		response.Error(c, apierror.Internal("Failed to reset password", err))
+		return
+	}
+
+	if err := h.lockout.Succeed(ctx, user.Username); err != nil {
+		requestLogger(c).Error("login lockout store error", "error", err)
+	}
+
+	response.NoContent(c)
+}
@@ -0,0 +1,440 @@
+package handlers_test
+
+import (
+	"blog/config"
+	"blog/database"
+	"blog/handlers"
+	"blog/mail"
+	"blog/middleware"
+	"blog/models"
+	"blog/ratelimit"
+	"blog/repository"
+	"blog/search"
+	"blog/service"
+	"blog/signedtoken"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	netmail "net/mail"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	// 迁移和请求日志对测试结果没有帮助
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// newRouter 在迁移好的内存 SQLite 数据库上组装认证、个人资料和文章接口
+func newRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	r, _ := newRouterWithDB(t)
+	return r
+}
+
+// newRouterWithDB 与 newRouter 相同，同时返回数据库，供测试直接修改数据
+func newRouterWithDB(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.Open(config.DatabaseConfig{Driver: config.DriverSQLite, DSN: ":memory:"}, config.LogLevelError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := middleware.SetupJWT(config.JWTConfig{
+		Secret:   "0123456789abcdef0123456789abcdef",
+		Issuer:   "blog",
+		Audience: "blog-api",
+		Expiry:   config.Duration(15 * time.Minute),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	postRepo := repository.NewPostRepository(db)
+	tokenService := service.NewTokenService(repository.NewTokenRepository(db), userRepo, middleware.GenerateToken, time.Hour)
+	emailService := service.NewEmailService(userRepo, tokenService, mail.NewLog(&netmail.Address{Address: "blog@localhost"}), signedtoken.New([]byte("mail-secret")), service.EmailOptions{
+		BaseURL:      "http://localhost",
+		VerifyExpiry: time.Hour,
+		ResetExpiry:  time.Hour,
+	})
+	lockout := ratelimit.NewLockout(ratelimit.NewMemory(), ratelimit.LockoutPolicy{})
+	index := search.NewMemoryIndex()
+	postService := service.NewPostService(postRepo, repository.NewTagRepository(db), repository.NewCategoryRepository(db),
+		repository.NewReactionRepository(db), index, service.NewViewCounter(postRepo))
+
+	userService := service.NewUserService(userRepo)
+	authHandler := handlers.NewAuthHandler(userService, tokenService, emailService, lockout)
+	adminHandler := handlers.NewAdminHandler(userService)
+	userHandler := handlers.NewUserHandler(service.NewAccountService(userRepo, tokenService, index), postService)
+	postHandler := handlers.NewPostHandler(postService)
+
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.ErrorHandler())
+	r.NoRoute(middleware.NoRoute)
+
+	api := r.Group("/api")
+	viewer := middleware.OptionalAuth(tokenService)
+	api.POST("/register", authHandler.Register)
+	api.POST("/login", authHandler.Login)
+	api.POST("/token/refresh", authHandler.Refresh)
+	api.GET("/posts", viewer, postHandler.GetPosts)
+	api.GET("/posts/:id", viewer, postHandler.GetPost)
+	api.GET("/posts/by-slug/:slug", viewer, postHandler.GetPostBySlug)
+
+	auth := api.Group("", middleware.AuthMiddleware(tokenService))
+	auth.POST("/logout", authHandler.Logout)
+	auth.GET("/me", userHandler.GetMe)
+	auth.DELETE("/me", userHandler.DeleteMe)
+	auth.POST("/posts", middleware.RequirePermission(models.PermPostCreate), postHandler.CreatePost)
+	auth.PUT("/posts/:id", postHandler.UpdatePost)
+	auth.DELETE("/posts/:id", postHandler.DeletePost)
+	auth.PATCH("/admin/users/:id/role", middleware.RequirePermission(models.PermUserManage), adminHandler.UpdateUserRole)
+	return r, db
+}
+
+// envelope 统一响应结构
+type envelope struct {
+	Data  json.RawMessage `json:"data"`
+	Error *struct {
+		Code string `json:"code"`
+	} `json:"error"`
+	RequestID string `json:"request_id"`
+}
+
+// do 发送请求并解析响应，body 不为 nil 时编码为 JSON
+func do(t *testing.T, r http.Handler, method, path, token string, body interface{}) (int, envelope) {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var env envelope
+	if w.Body.Len() > 0 {
+		if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+			t.Fatalf("%s %s: decode %q: %v", method, path, w.Body.String(), err)
+		}
+	}
+	return w.Code, env
+}
+
+// errorCode 返回错误响应的错误码，成功响应返回空字符串
+func (e envelope) errorCode() string {
+	if e.Error == nil {
+		return ""
+	}
+	return e.Error.Code
+}
+
+// decode 把 data 解析到 v
+func (e envelope) decode(t *testing.T, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		t.Fatalf("decode data %s: %v", e.Data, err)
+	}
+}
+
+// login 注册并登录用户，返回访问令牌
+func login(t *testing.T, r http.Handler, username string) string {
+	t.Helper()
+	token, _ := loginPair(t, r, username)
+	return token
+}
+
+// loginPair 注册并登录用户，返回访问令牌和刷新令牌
+func loginPair(t *testing.T, r http.Handler, username string) (string, string) {
+	t.Helper()
+	status, env := do(t, r, http.MethodPost, "/api/register", "", gin.H{
+		"username": username, "password": "secret1", "email": username + "@example.com",
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("register %s: status %d, error %q", username, status, env.errorCode())
+	}
+	status, env = do(t, r, http.MethodPost, "/api/login", "", gin.H{"username": username, "password": "secret1"})
+	if status != http.StatusOK {
+		t.Fatalf("login %s: status %d, error %q", username, status, env.errorCode())
+	}
+	var data struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	env.decode(t, &data)
+	return data.Token, data.RefreshToken
+}
+
+func TestAuth(t *testing.T) {
+	r := newRouter(t)
+	token := login(t, r, "alice")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   interface{}
+		status int
+		code   string
+	}{
+		{"duplicate username", http.MethodPost, "/api/register", "", gin.H{"username": "alice", "password": "secret1", "email": "other@example.com"}, http.StatusConflict, "username_taken"},
+		{"duplicate email", http.MethodPost, "/api/register", "", gin.H{"username": "bob", "password": "secret1", "email": "alice@example.com"}, http.StatusConflict, "email_taken"},
+		{"invalid email", http.MethodPost, "/api/register", "", gin.H{"username": "bob", "password": "secret1", "email": "nope"}, http.StatusBadRequest, "validation_failed"},
+		{"wrong password", http.MethodPost, "/api/login", "", gin.H{"username": "alice", "password": "wrong"}, http.StatusUnauthorized, "invalid_credentials"},
+		{"unknown user", http.MethodPost, "/api/login", "", gin.H{"username": "nobody", "password": "secret1"}, http.StatusUnauthorized, "invalid_credentials"},
+		{"me without token", http.MethodGet, "/api/me", "", nil, http.StatusUnauthorized, "unauthorized"},
+		{"me with bad token", http.MethodGet, "/api/me", "not-a-jwt", nil, http.StatusUnauthorized, "invalid_token"},
+		{"me", http.MethodGet, "/api/me", token, nil, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, env := do(t, r, tt.method, tt.path, tt.token, tt.body)
+			if status != tt.status || env.errorCode() != tt.code {
+				t.Fatalf("status %d, error %q; want %d, %q", status, env.errorCode(), tt.status, tt.code)
+			}
+			if env.RequestID == "" {
+				t.Error("response has no request_id")
+			}
+		})
+	}
+
+	// 注销后访问令牌立即失效
+	if status, _ := do(t, r, http.MethodPost, "/api/logout", token, gin.H{}); status != http.StatusNoContent {
+		t.Fatalf("logout: status %d", status)
+	}
+	if status, env := do(t, r, http.MethodGet, "/api/me", token, nil); status != http.StatusUnauthorized {
+		t.Fatalf("me after logout: status %d, error %q", status, env.errorCode())
+	}
+}
+
+func TestRefreshRotation(t *testing.T) {
+	r := newRouter(t)
+	_, refresh := loginPair(t, r, "alice")
+
+	status, env := do(t, r, http.MethodPost, "/api/token/refresh", "", gin.H{"refresh_token": refresh})
+	if status != http.StatusOK {
+		t.Fatalf("refresh: status %d, error %q", status, env.errorCode())
+	}
+	var data struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	env.decode(t, &data)
+	if data.RefreshToken == "" || data.RefreshToken == refresh {
+		t.Fatalf("refresh token was not rotated: %q", data.RefreshToken)
+	}
+
+	// 重放已轮换的刷新令牌会作废整个登录会话
+	for _, token := range []string{refresh, data.RefreshToken} {
+		status, env := do(t, r, http.MethodPost, "/api/token/refresh", "", gin.H{"refresh_token": token})
+		if status != http.StatusUnauthorized || env.errorCode() != "invalid_refresh_token" {
+			t.Fatalf("refresh with revoked family: status %d, error %q", status, env.errorCode())
+		}
+	}
+}
+
+func TestDeletedUserTokensRejected(t *testing.T) {
+	r := newRouter(t)
+	current := login(t, r, "alice")
+	status, env := do(t, r, http.MethodPost, "/api/login", "", gin.H{"username": "alice", "password": "secret1"})
+	if status != http.StatusOK {
+		t.Fatalf("second login: status %d, error %q", status, env.errorCode())
+	}
+	var other struct {
+		Token string `json:"token"`
+	}
+	env.decode(t, &other)
+
+	if status, env := do(t, r, http.MethodDelete, "/api/me", current, gin.H{"password": "secret1"}); status != http.StatusNoContent {
+		t.Fatalf("delete: status %d, error %q", status, env.errorCode())
+	}
+
+	// 另一个会话的访问令牌没有加入黑名单，但用户已注销
+	if status, env := do(t, r, http.MethodGet, "/api/me", other.Token, nil); status != http.StatusUnauthorized || env.errorCode() != "invalid_token" {
+		t.Fatalf("me after delete: status %d, error %q", status, env.errorCode())
+	}
+}
+
+func TestRoleChangeAppliesToIssuedTokens(t *testing.T) {
+	r, db := newRouterWithDB(t)
+	alice := login(t, r, "alice")
+	admin := login(t, r, "admin")
+	if err := service.NewUserService(repository.NewUserRepository(db)).SetRoleByUsername(context.Background(), "admin", models.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	status, env := do(t, r, http.MethodGet, "/api/me", alice, nil)
+	if status != http.StatusOK {
+		t.Fatalf("me: status %d, error %q", status, env.errorCode())
+	}
+	var me struct {
+		ID uint `json:"id"`
+	}
+	env.decode(t, &me)
+
+	// 提升为管理员之前签发的令牌也立即拥有管理权限
+	path := fmt.Sprintf("/api/admin/users/%d/role", me.ID)
+	if status, env := do(t, r, http.MethodPatch, path, admin, gin.H{"role": "reader"}); status != http.StatusOK {
+		t.Fatalf("set role: status %d, error %q", status, env.errorCode())
+	}
+
+	// 降级为 reader 后，降级前签发的令牌不能再发表文章
+	status, env = do(t, r, http.MethodPost, "/api/posts", alice, gin.H{"title": "Hello", "content": "world"})
+	if status != http.StatusForbidden || env.errorCode() != "forbidden" {
+		t.Fatalf("create after demotion: status %d, error %q", status, env.errorCode())
+	}
+}
+
+func TestPostCRUD(t *testing.T) {
+	r := newRouter(t)
+	alice := login(t, r, "alice")
+	bob := login(t, r, "bob")
+
+	status, env := do(t, r, http.MethodPost, "/api/posts", alice, gin.H{"title": "Hello", "content": "**world**", "status": "published"})
+	if status != http.StatusCreated {
+		t.Fatalf("create: status %d, error %q", status, env.errorCode())
+	}
+	var post struct {
+		ID          uint   `json:"id"`
+		Title       string `json:"title"`
+		Slug        string `json:"slug"`
+		Content     string `json:"content"`
+		ContentHTML string `json:"content_html"`
+		Excerpt     string `json:"excerpt"`
+		Tags        []struct {
+			Name string `json:"name"`
+		} `json:"tags"`
+		User struct {
+			Username string `json:"username"`
+			Email    string `json:"email"`
+		} `json:"user"`
+	}
+	env.decode(t, &post)
+	if post.Slug != "hello" || !strings.Contains(post.ContentHTML, "<strong>world</strong>") || post.Excerpt != "world" || post.User.Username != "alice" {
+		t.Fatalf("created post = %+v", post)
+	}
+	path := fmt.Sprintf("/api/posts/%d", post.ID)
+
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		body   interface{}
+		status int
+		code   string
+	}{
+		{"create without token", http.MethodPost, "", gin.H{"title": "x", "content": "y"}, http.StatusUnauthorized, "unauthorized"},
+		{"create without title", http.MethodPost, alice, gin.H{"content": "y"}, http.StatusBadRequest, "validation_failed"},
+		{"update by another user", http.MethodPut, bob, gin.H{"title": "Hijacked"}, http.StatusForbidden, "forbidden"},
+		{"delete by another user", http.MethodDelete, bob, nil, http.StatusForbidden, "forbidden"},
+		{"update by author", http.MethodPut, alice, gin.H{"title": "Hello again", "tags": []string{"go"}}, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := path
+			if tt.method == http.MethodPost {
+				p = "/api/posts"
+			}
+			status, env := do(t, r, tt.method, p, tt.token, tt.body)
+			if status != tt.status || env.errorCode() != tt.code {
+				t.Fatalf("status %d, error %q; want %d, %q", status, env.errorCode(), tt.status, tt.code)
+			}
+		})
+	}
+
+	status, env = do(t, r, http.MethodGet, path, "", nil)
+	if status != http.StatusOK {
+		t.Fatalf("get: status %d, error %q", status, env.errorCode())
+	}
+	env.decode(t, &post)
+	if post.Title != "Hello again" || post.Content != "**world**" || len(post.Tags) != 1 || post.Tags[0].Name != "go" {
+		t.Errorf("post = %+v, want the new title and tags and the old content", post)
+	}
+	if post.User.Email != "" {
+		t.Errorf("post author exposes email %q", post.User.Email)
+	}
+
+	// 旧 slug 重定向到新 slug，并保留查询参数
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/posts/by-slug/hello?ref=feed", nil))
+	if want := "/api/posts/by-slug/" + post.Slug + "?ref=feed"; w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != want {
+		t.Errorf("old slug: status %d, Location %q; want %d, %q", w.Code, w.Header().Get("Location"), http.StatusMovedPermanently, want)
+	}
+
+	status, env = do(t, r, http.MethodGet, "/api/posts", "", nil)
+	var list []struct {
+		ID uint `json:"id"`
+	}
+	env.decode(t, &list)
+	if status != http.StatusOK || len(list) != 1 || list[0].ID != post.ID {
+		t.Fatalf("list: status %d, posts %+v", status, list)
+	}
+
+	if status, env := do(t, r, http.MethodDelete, path, alice, nil); status != http.StatusNoContent {
+		t.Fatalf("delete: status %d, error %q", status, env.errorCode())
+	}
+	if status, env := do(t, r, http.MethodGet, path, "", nil); status != http.StatusNotFound || env.errorCode() != "not_found" {
+		t.Fatalf("get deleted: status %d, error %q", status, env.errorCode())
+	}
+}
+
+func TestDraftVisibleToAuthorOnly(t *testing.T) {
+	r := newRouter(t)
+	alice := login(t, r, "alice")
+	bob := login(t, r, "bob")
+
+	status, env := do(t, r, http.MethodPost, "/api/posts", alice, gin.H{"title": "Draft", "content": "wip", "status": "draft"})
+	if status != http.StatusCreated {
+		t.Fatalf("create: status %d, error %q", status, env.errorCode())
+	}
+	var post struct {
+		ID uint `json:"id"`
+	}
+	env.decode(t, &post)
+	path := fmt.Sprintf("/api/posts/%d", post.ID)
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+		code   string
+	}{
+		{"anonymous", "", http.StatusNotFound, "not_found"},
+		{"another user", bob, http.StatusNotFound, "not_found"},
+		{"author", alice, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, env := do(t, r, http.MethodGet, path, tt.token, nil); status != tt.status || env.errorCode() != tt.code {
+				t.Fatalf("status %d, error %q; want %d, %q", status, env.errorCode(), tt.status, tt.code)
+			}
+		})
+	}
+}
@@ -1,185 +1,454 @@
 package handlers
 
 import (
-	"blog/database"
+	"blog/apierror"
 	"blog/middleware"
 	"blog/models"
-	"log"
+	"blog/repository"
+	"blog/response"
+	"blog/service"
+	"errors"
 	"net/http"
+	"net/url"
+	"path"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// CreatePostRequest 创建文章请求结构
+// CreatePostRequest 创建文章请求结构。status 默认为 published，
+// 为 scheduled 时 publish_at（RFC3339）必须晚于当前时间。
 type CreatePostRequest struct {
-	Title   string `json:"title" binding:"required"`
-	Content string `json:"content" binding:"required"`
+	Title      string     `json:"title" binding:"required"`
+	Content    string     `json:"content" binding:"required"`
+	CategoryID *uint      `json:"category_id"`
+	Tags       []string   `json:"tags"`
+	Status     string     `json:"status" binding:"omitempty,oneof=draft scheduled published"`
+	PublishAt  *time.Time `json:"publish_at"`
 }
 
-// UpdatePostRequest 更新文章请求结构
+// UpdatePostRequest 更新文章请求结构。
+// category_id 为 0 表示取消分类；tags 不传表示不修改，传空数组表示清空。
 type UpdatePostRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title      string     `json:"title"`
+	Content    string     `json:"content"`
+	CategoryID *uint      `json:"category_id"`
+	Tags       *[]string  `json:"tags"`
+	Status     string     `json:"status" binding:"omitempty,oneof=draft scheduled published archived"`
+	PublishAt  *time.Time `json:"publish_at"`
+}
+
+// AttachTagsRequest 为文章追加标签请求结构
+type AttachTagsRequest struct {
+	Tags []string `json:"tags" binding:"required,min=1"`
+}
+
+// ListPostsQuery 文章列表查询参数
+type ListPostsQuery struct {
+	Page       int    `form:"page" binding:"omitempty,min=1"`
+	PageSize   int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Cursor     string `form:"cursor"`
+	Sort       string `form:"sort" binding:"omitempty,oneof=created_at updated_at comment_count"`
+	Order      string `form:"order" binding:"omitempty,oneof=asc desc"`
+	AuthorID   uint   `form:"author_id"`
+	Tag        string `form:"tag"`
+	CategoryID uint   `form:"category_id"`
+	From       string `form:"from"` // RFC3339 或 YYYY-MM-DD，包含
+	To         string `form:"to"`   // RFC3339 或 YYYY-MM-DD（包含当天），不包含
+}
+
+// ListMyPostsQuery 当前用户文章列表查询参数，可以按状态过滤
+type ListMyPostsQuery struct {
+	ListPostsQuery
+	Status string `form:"status" binding:"omitempty,oneof=draft scheduled published archived"`
+}
+
+// PostHandler 文章接口
+type PostHandler struct {
+	posts *service.PostService
+}
+
+// NewPostHandler 创建 PostHandler
+func NewPostHandler(posts *service.PostService) *PostHandler {
+	return &PostHandler{posts: posts}
 }
 
 // CreatePost 创建文章
-func CreatePost(c *gin.Context) {
-	userID := middleware.GetUserID(c)
-	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+func (h *PostHandler) CreatePost(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
 		return
 	}
 
 	var req CreatePostRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		log.Printf("CreatePost validation error: %v", err)
+		response.Error(c, apierror.FromBinding(err))
 		return
 	}
 
-	post := models.Post{
-		Title:   req.Title,
-		Content: req.Content,
-		UserID:  userID,
+	post, err := h.posts.Create(c.Request.Context(), userID, service.CreatePostInput{
+		Title:      req.Title,
+		Content:    req.Content,
+		CategoryID: req.CategoryID,
+		Tags:       req.Tags,
+		Status:     models.PostStatus(req.Status),
+		PublishAt:  req.PublishAt,
+	})
+	switch apiErr := postInputError(err); {
+	case apiErr != nil:
+		response.Error(c, apiErr)
+		return
+	case err != nil:
+		response.Error(c, apierror.Internal("Failed to create post", err))
+		return
 	}
 
-	if err := database.DB.Create(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
-		log.Printf("Post creation error: %v", err)
+	requestLogger(c).Info("post created", "post_id", post.ID)
+	response.Created(c, post)
+}
+
+// GetPosts 分页获取已发布的文章列表，支持排序、按作者、标签、分类和创建时间过滤
+func (h *PostHandler) GetPosts(c *gin.Context) {
+	var query ListPostsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.Error(c, apierror.FromBinding(err))
 		return
 	}
 
-	// 加载用户信息
-	database.DB.Preload("User").First(&post, post.ID)
+	in, ok := listPostsInput(c, query)
+	if !ok {
+		return
+	}
+	in.Viewer = actorFrom(c).ID
+	page, err := h.posts.List(c.Request.Context(), in)
+	respondPostPage(c, page, err)
+}
 
-	log.Printf("Post created successfully: ID=%d, UserID=%d", post.ID, userID)
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Post created successfully",
-		"post":    post,
-	})
+// GetMyPosts 分页获取当前用户的文章，包括草稿、定时和已归档的文章
+func (h *PostHandler) GetMyPosts(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var query ListMyPostsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	in, ok := listPostsInput(c, query.ListPostsQuery)
+	if !ok {
+		return
+	}
+	in.Status = models.PostStatus(query.Status)
+	page, err := h.posts.ListOwn(c.Request.Context(), userID, in)
+	respondPostPage(c, page, err)
 }
 
-// GetPosts 获取所有文章列表
-func GetPosts(c *gin.Context) {
-	var posts []models.Post
-	if err := database.DB.Preload("User").Preload("Comments.User").Order("created_at desc").Find(&posts).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
-		log.Printf("GetPosts error: %v", err)
+// GetMyBookmarks 分页获取当前用户收藏的已发布文章，查询参数与文章列表相同
+func (h *PostHandler) GetMyBookmarks(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"posts": posts,
-		"count": len(posts),
-	})
+	var query ListPostsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	in, ok := listPostsInput(c, query)
+	if !ok {
+		return
+	}
+	page, err := h.posts.ListBookmarks(c.Request.Context(), userID, in)
+	respondPostPage(c, page, err)
 }
 
-// GetPost 获取单个文章详情
-func GetPost(c *gin.Context) {
-	postID := c.Param("id")
+// listPostsInput 把查询参数转换为 service 参数，日期格式错误时写入 400 响应并返回 false
+func listPostsInput(c *gin.Context, query ListPostsQuery) (service.ListPostsInput, bool) {
+	from, err := parseDateParam(query.From, false)
+	if err != nil {
+		response.Error(c, apierror.Invalid("from", "date", "Invalid from date, use RFC3339 or YYYY-MM-DD"))
+		return service.ListPostsInput{}, false
+	}
+	to, err := parseDateParam(query.To, true)
+	if err != nil {
+		response.Error(c, apierror.Invalid("to", "date", "Invalid to date, use RFC3339 or YYYY-MM-DD"))
+		return service.ListPostsInput{}, false
+	}
+
+	return service.ListPostsInput{
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		Cursor:     query.Cursor,
+		Sort:       repository.PostSort(query.Sort),
+		Asc:        query.Order == "asc",
+		AuthorID:   query.AuthorID,
+		Tag:        query.Tag,
+		CategoryID: query.CategoryID,
+		From:       from,
+		To:         to,
+	}, true
+}
 
-	var post models.Post
-	if err := database.DB.Preload("User").Preload("Comments.User").First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
-		log.Printf("GetPost error: post ID %s not found", postID)
+// respondPostPage 输出一页文章及分页信息
+func respondPostPage(c *gin.Context, page *service.PostPage, err error) {
+	if errors.Is(err, service.ErrInvalidCursor) {
+		response.Error(c, apierror.Invalid("cursor", "cursor", "Invalid cursor"))
+		return
+	}
+	if err != nil {
+		response.Error(c, apierror.Internal("Failed to fetch posts", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"post": post,
-	})
+	response.List(c, page.Posts, postPageMeta(page))
 }
 
-// UpdatePost 更新文章
-func UpdatePost(c *gin.Context) {
-	userID := middleware.GetUserID(c)
-	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+// postPageMeta 一页文章的分页信息
+func postPageMeta(page *service.PostPage) gin.H {
+	totalPages := (page.Total + int64(page.PageSize) - 1) / int64(page.PageSize)
+	return gin.H{
+		"page":        page.Page,
+		"page_size":   page.PageSize,
+		"total":       page.Total,
+		"total_pages": totalPages,
+		"has_more":    page.NextCursor != "",
+		"next_cursor": page.NextCursor,
+	}
+}
+
+// parseDateParam 解析 RFC3339 或 YYYY-MM-DD 格式的日期，空字符串返回 nil。
+// endOfDay 为 true 时，纯日期会被解析为次日零点，作为不包含的上界以覆盖当天。
+func parseDateParam(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// GetPost 获取单个文章详情，作者和版主登录后可以查看未发布的文章
+func (h *PostHandler) GetPost(c *gin.Context) {
+	postID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid post ID"))
+		return
+	}
+
+	post, err := h.posts.Get(c.Request.Context(), actorFrom(c), postID)
+	if errors.Is(err, service.ErrPostNotFound) {
+		if respondViewerTokenInvalid(c) {
+			return
+		}
+		response.Error(c, apierror.NotFound("Post not found"))
+		requestLogger(c).Debug("post not found", "post_id", postID)
+		return
+	}
+	if err != nil {
+		response.Error(c, apierror.Internal("Failed to fetch post", err))
+		return
+	}
+
+	response.OK(c, post)
+}
+
+// GetPostBySlug 按 slug 获取文章详情，使用旧 slug 访问时永久重定向到当前 slug 的地址
+func (h *PostHandler) GetPostBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+	post, err := h.posts.GetBySlug(c.Request.Context(), actorFrom(c), slug)
+	if errors.Is(err, service.ErrPostNotFound) {
+		if respondViewerTokenInvalid(c) {
+			return
+		}
+		response.Error(c, apierror.NotFound("Post not found"))
+		return
+	}
+	if err != nil {
+		response.Error(c, apierror.Internal("Failed to fetch post", err))
 		return
 	}
 
-	postID := c.Param("id")
+	if post.Slug != slug {
+		target := path.Join(path.Dir(c.Request.URL.Path), url.PathEscape(post.Slug))
+		if query := c.Request.URL.RawQuery; query != "" {
+			target += "?" + query
+		}
+		c.Redirect(http.StatusMovedPermanently, target)
+		return
+	}
+	response.OK(c, post)
+}
 
-	// 查找文章
-	var post models.Post
-	if err := database.DB.First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
-		log.Printf("UpdatePost error: post ID %s not found", postID)
+// UpdatePost 更新文章
+func (h *PostHandler) UpdatePost(c *gin.Context) {
+	_, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
 		return
 	}
 
-	// 检查是否是文章作者
-	if post.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You can only update your own posts"})
-		log.Printf("UpdatePost error: user %d tried to update post %d owned by user %d", userID, post.ID, post.UserID)
+	postID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid post ID"))
 		return
 	}
 
 	var req UpdatePostRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		log.Printf("UpdatePost validation error: %v", err)
+		response.Error(c, apierror.FromBinding(err))
 		return
 	}
 
-	// 更新文章
-	if req.Title != "" {
-		post.Title = req.Title
+	post, err := h.posts.Update(c.Request.Context(), actorFrom(c), postID, service.UpdatePostInput{
+		Title:      req.Title,
+		Content:    req.Content,
+		CategoryID: req.CategoryID,
+		Tags:       req.Tags,
+		Status:     models.PostStatus(req.Status),
+		PublishAt:  req.PublishAt,
+	})
+	switch apiErr := postInputError(err); {
+	case apiErr != nil:
+		response.Error(c, apiErr)
+		return
+	case errors.Is(err, service.ErrPostNotFound):
+		response.Error(c, apierror.NotFound("Post not found"))
+		requestLogger(c).Debug("post not found", "post_id", postID)
+		return
+	case errors.Is(err, service.ErrForbidden):
+		response.Error(c, apierror.Forbidden("You can only update your own posts"))
+		requestLogger(c).Warn("update post denied: owned by another user", "post_id", postID)
+		return
+	case err != nil:
+		response.Error(c, apierror.Internal("Failed to update post", err))
+		return
 	}
-	if req.Content != "" {
-		post.Content = req.Content
+
+	requestLogger(c).Info("post updated", "post_id", post.ID)
+	response.OK(c, post)
+}
+
+// DeletePost 删除文章
+func (h *PostHandler) DeletePost(c *gin.Context) {
+	_, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
+		return
 	}
 
-	if err := database.DB.Save(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
-		log.Printf("Post update error: %v", err)
+	postID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid post ID"))
 		return
 	}
 
-	// 重新加载用户信息
-	database.DB.Preload("User").First(&post, post.ID)
+	err = h.posts.Delete(c.Request.Context(), actorFrom(c), postID)
+	switch {
+	case errors.Is(err, service.ErrPostNotFound):
+		response.Error(c, apierror.NotFound("Post not found"))
+		requestLogger(c).Debug("post not found", "post_id", postID)
+		return
+	case errors.Is(err, service.ErrForbidden):
+		response.Error(c, apierror.Forbidden("You can only delete your own posts"))
+		requestLogger(c).Warn("delete post denied: owned by another user", "post_id", postID)
+		return
+	case err != nil:
+		response.Error(c, apierror.Internal("Failed to delete post", err))
+		return
+	}
 
-	log.Printf("Post updated successfully: ID=%d", post.ID)
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Post updated successfully",
-		"post":    post,
-	})
+	requestLogger(c).Info("post deleted", "post_id", postID)
+	response.NoContent(c)
 }
 
-// DeletePost 删除文章
-func DeletePost(c *gin.Context) {
-	userID := middleware.GetUserID(c)
-	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+// AttachTags 为文章追加标签
+func (h *PostHandler) AttachTags(c *gin.Context) {
+	postID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid post ID"))
 		return
 	}
 
-	postID := c.Param("id")
+	var req AttachTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
 
-	// 查找文章
-	var post models.Post
-	if err := database.DB.First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
-		log.Printf("DeletePost error: post ID %s not found", postID)
+	post, err := h.posts.AttachTags(c.Request.Context(), actorFrom(c), postID, req.Tags)
+	switch apiErr := postInputError(err); {
+	case apiErr != nil:
+		response.Error(c, apiErr)
+		return
+	case errors.Is(err, service.ErrPostNotFound):
+		response.Error(c, apierror.NotFound("Post not found"))
+		return
+	case errors.Is(err, service.ErrForbidden):
+		response.Error(c, apierror.Forbidden("You can only tag your own posts"))
+		return
+	case err != nil:
+		response.Error(c, apierror.Internal("Failed to attach tags", err))
 		return
 	}
 
-	// 检查是否是文章作者
-	if post.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own posts"})
-		log.Printf("DeletePost error: user %d tried to delete post %d owned by user %d", userID, post.ID, post.UserID)
+	response.OK(c, post)
+}
+
+// DetachTag 解除文章与标签的关联
+func (h *PostHandler) DetachTag(c *gin.Context) {
+	postID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid post ID"))
 		return
 	}
 
-	// 删除文章（级联删除评论）
-	if err := database.DB.Delete(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
-		log.Printf("Post deletion error: %v", err)
+	post, err := h.posts.DetachTag(c.Request.Context(), actorFrom(c), postID, c.Param("tag"))
+	switch {
+	case errors.Is(err, service.ErrPostNotFound):
+		response.Error(c, apierror.NotFound("Post not found"))
+		return
+	case errors.Is(err, service.ErrTagNotFound):
+		response.Error(c, apierror.NotFound("Post does not have this tag"))
+		return
+	case errors.Is(err, service.ErrForbidden):
+		response.Error(c, apierror.Forbidden("You can only tag your own posts"))
+		return
+	case err != nil:
+		response.Error(c, apierror.Internal("Failed to detach tag", err))
 		return
 	}
 
-	log.Printf("Post deleted successfully: ID=%d", post.ID)
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Post deleted successfully",
-	})
+	response.OK(c, post)
+}
+
+// postInputError 返回标签、分类和发布状态校验错误对应的 API 错误，其他错误返回 nil
+func postInputError(err error) *apierror.Error {
+	switch {
+	case errors.Is(err, service.ErrInvalidSchedule):
+		return apierror.Invalid("publish_at", "future", "Scheduled posts need a publish_at in the future")
+	case errors.Is(err, service.ErrInvalidStatus):
+		return apierror.Invalid("status", "oneof", "Invalid post status")
+	case errors.Is(err, service.ErrInvalidTag):
+		return apierror.Invalid("tags", "tag", "Tags must be 1-50 characters, at most 10 per post")
+	case errors.Is(err, service.ErrCategoryNotFound):
+		return apierror.Invalid("category_id", "exists", "Category not found")
+	default:
+		return nil
+	}
 }
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"blog/apierror"
+	"blog/middleware"
+	"blog/response"
+	"blog/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReactionHandler 点赞和收藏接口，PUT 表示点赞/收藏，DELETE 表示取消，重复调用结果相同
+type ReactionHandler struct {
+	reactions *service.ReactionService
+}
+
+// NewReactionHandler 创建 ReactionHandler
+func NewReactionHandler(reactions *service.ReactionService) *ReactionHandler {
+	return &ReactionHandler{reactions: reactions}
+}
+
+// Like 点赞文章
+func (h *ReactionHandler) Like(c *gin.Context) {
+	h.setLike(c, true)
+}
+
+// Unlike 取消点赞
+func (h *ReactionHandler) Unlike(c *gin.Context) {
+	h.setLike(c, false)
+}
+
+func (h *ReactionHandler) setLike(c *gin.Context, liked bool) {
+	userID, postID, ok := reactionParams(c)
+	if !ok {
+		return
+	}
+
+	var count int64
+	var err error
+	if liked {
+		count, err = h.reactions.Like(c.Request.Context(), userID, postID)
+	} else {
+		count, err = h.reactions.Unlike(c.Request.Context(), userID, postID)
+	}
+	if respondReactionError(c, err, "Failed to update like") {
+		return
+	}
+
+	response.OK(c, gin.H{
+		"post_id":    postID,
+		"liked":      liked,
+		"like_count": count,
+	})
+}
+
+// Bookmark 收藏文章
+func (h *ReactionHandler) Bookmark(c *gin.Context) {
+	h.setBookmark(c, true)
+}
+
+// Unbookmark 取消收藏
+func (h *ReactionHandler) Unbookmark(c *gin.Context) {
+	h.setBookmark(c, false)
+}
+
+func (h *ReactionHandler) setBookmark(c *gin.Context, bookmarked bool) {
+	userID, postID, ok := reactionParams(c)
+	if !ok {
+		return
+	}
+
+	var err error
+	if bookmarked {
+		err = h.reactions.Bookmark(c.Request.Context(), userID, postID)
+	} else {
+		err = h.reactions.Unbookmark(c.Request.Context(), userID, postID)
+	}
+	if respondReactionError(c, err, "Failed to update bookmark") {
+		return
+	}
+
+	response.OK(c, gin.H{
+		"post_id":    postID,
+		"bookmarked": bookmarked,
+	})
+}
+
+// reactionParams 取出当前用户和路径中的文章 ID，失败时写入响应并返回 false
+func reactionParams(c *gin.Context) (userID, postID uint, ok bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
+		return 0, 0, false
+	}
+	postID, ok = parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid post ID"))
+		return 0, 0, false
+	}
+	return userID, postID, true
+}
+
+// respondReactionError 把点赞、收藏接口的错误写成响应，其他错误按 message 作为内部错误输出。
+// err 为空时返回 false，否则返回 true
+func respondReactionError(c *gin.Context, err error, message string) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, service.ErrPostNotFound):
+		response.Error(c, apierror.NotFound("Post not found"))
+	default:
+		response.Error(c, apierror.Internal(message, err))
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"blog/apierror"
+	"blog/response"
+	"blog/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DiffRevisionsQuery 版本比较查询参数，to 不传时与最新版本比较
+type DiffRevisionsQuery struct {
+	From int `form:"from" binding:"required,min=1"`
+	To   int `form:"to" binding:"omitempty,min=1"`
+}
+
+// ListRevisions 获取文章的版本列表
+func (h *PostHandler) ListRevisions(c *gin.Context) {
+	postID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid post ID"))
+		return
+	}
+
+	revisions, err := h.posts.Revisions(c.Request.Context(), actorFrom(c), postID)
+	if respondRevisionError(c, err, "Failed to fetch revisions") {
+		return
+	}
+
+	response.List(c, revisions, nil)
+}
+
+// GetRevision 获取文章某个版本的完整内容
+func (h *PostHandler) GetRevision(c *gin.Context) {
+	postID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid post ID"))
+		return
+	}
+	version, ok := parseID(c, "version")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid revision version"))
+		return
+	}
+
+	revision, err := h.posts.Revision(c.Request.Context(), actorFrom(c), postID, int(version))
+	if respondRevisionError(c, err, "Failed to fetch revision") {
+		return
+	}
+
+	response.OK(c, revision)
+}
+
+// DiffRevisions 按行比较文章的两个版本
+func (h *PostHandler) DiffRevisions(c *gin.Context) {
+	postID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid post ID"))
+		return
+	}
+
+	var query DiffRevisionsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	d, err := h.posts.DiffRevisions(c.Request.Context(), actorFrom(c), postID, query.From, query.To)
+	if respondRevisionError(c, err, "Failed to compare revisions") {
+		return
+	}
+
+	response.OK(c, d)
+}
+
+// RestoreRevision 把文章恢复为某个版本
+func (h *PostHandler) RestoreRevision(c *gin.Context) {
+	postID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid post ID"))
+		return
+	}
+	version, ok := parseID(c, "version")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid revision version"))
+		return
+	}
+
+	post, err := h.posts.RestoreRevision(c.Request.Context(), actorFrom(c), postID, int(version))
+	if respondRevisionError(c, err, "Failed to restore revision") {
+		return
+	}
+
+	requestLogger(c).Info("post restored", "post_id", postID, "version", version)
+	response.OK(c, post)
+}
+
+// respondRevisionError 把版本接口的错误写成响应，其他错误按 message 作为内部错误输出。
+// err 为空时返回 false，否则返回 true
+func respondRevisionError(c *gin.Context, err error, message string) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, service.ErrPostNotFound):
+		response.Error(c, apierror.NotFound("Post not found"))
+	case errors.Is(err, service.ErrRevisionNotFound):
+		response.Error(c, apierror.NotFound("Revision not found"))
+	case errors.Is(err, service.ErrForbidden):
+		response.Error(c, apierror.Forbidden("You can only access the history of your own posts"))
+	default:
+		response.Error(c, apierror.Internal(message, err))
+	}
+	return true
+}
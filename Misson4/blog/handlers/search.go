@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"blog/apierror"
+	"blog/response"
+	"blog/service"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchQuery 搜索查询参数
+type SearchQuery struct {
+	Q     string `form:"q" binding:"required"`
+	Type  string `form:"type" binding:"omitempty,oneof=post comment"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+// SearchHandler 搜索接口
+type SearchHandler struct {
+	search *service.SearchService
+}
+
+// NewSearchHandler 创建 SearchHandler
+func NewSearchHandler(search *service.SearchService) *SearchHandler {
+	return &SearchHandler{search: search}
+}
+
+// Search 搜索文章标题、内容和评论，结果按相关度排序并带高亮摘要
+func (h *SearchHandler) Search(c *gin.Context) {
+	var query SearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	results, err := h.search.Search(c.Request.Context(), strings.TrimSpace(query.Q), query.Type, query.Limit)
+	if errors.Is(err, service.ErrInvalidQuery) {
+		response.Error(c, apierror.Invalid("q", "max", "Search query must be 1-100 characters"))
+		return
+	}
+	if err != nil {
+		response.Error(c, apierror.Internal("Failed to search", err))
+		return
+	}
+
+	response.List(c, results, gin.H{"query": query.Q})
+}
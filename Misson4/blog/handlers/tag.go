@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"blog/apierror"
+	"blog/response"
+	"blog/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateCategoryRequest 创建分类请求结构
+type CreateCategoryRequest struct {
+	Name        string `json:"name" binding:"required,max=50"`
+	Description string `json:"description" binding:"max=255"`
+}
+
+// TagHandler 标签和分类接口
+type TagHandler struct {
+	tags *service.TagService
+}
+
+// NewTagHandler 创建 TagHandler
+func NewTagHandler(tags *service.TagService) *TagHandler {
+	return &TagHandler{tags: tags}
+}
+
+// ListTags 获取有文章的标签及各自的文章数
+func (h *TagHandler) ListTags(c *gin.Context) {
+	tags, err := h.tags.ListTags(c.Request.Context())
+	if err != nil {
+		response.Error(c, apierror.Internal("Failed to fetch tags", err))
+		return
+	}
+
+	response.List(c, tags, nil)
+}
+
+// ListCategories 获取全部分类及各自的文章数
+func (h *TagHandler) ListCategories(c *gin.Context) {
+	categories, err := h.tags.ListCategories(c.Request.Context())
+	if err != nil {
+		response.Error(c, apierror.Internal("Failed to fetch categories", err))
+		return
+	}
+
+	response.List(c, categories, nil)
+}
+
+// CreateCategory 创建分类
+func (h *TagHandler) CreateCategory(c *gin.Context) {
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	category, err := h.tags.CreateCategory(c.Request.Context(), service.CreateCategoryInput{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if errors.Is(err, service.ErrCategoryExists) {
+		response.Error(c, apierror.Conflict(apierror.CodeConflict, "Category already exists"))
+		return
+	}
+	if err != nil {
+		response.Error(c, apierror.Internal("Failed to create category", err))
+		return
+	}
+
+	requestLogger(c).Info("category created", "category_id", category.ID, "name", category.Name)
+	response.Created(c, category)
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"blog/apierror"
+	"blog/middleware"
+	"blog/models"
+	"blog/response"
+	"blog/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateProfileRequest 修改个人资料请求结构，不传的字段不修改，传空字符串表示清空
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+	AvatarURL   *string `json:"avatar_url"`
+}
+
+// ChangePasswordRequest 修改密码请求结构
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// DeleteAccountRequest 注销账号请求结构。delete_content 为 true 时一并删除文章、评论和点赞，
+// 否则保留这些内容并匿名显示作者。
+type DeleteAccountRequest struct {
+	Password      string `json:"password" binding:"required"`
+	DeleteContent bool   `json:"delete_content"`
+}
+
+// UserHandler 个人资料和账号管理接口
+type UserHandler struct {
+	accounts *service.AccountService
+	posts    *service.PostService
+}
+
+// NewUserHandler 创建 UserHandler
+func NewUserHandler(accounts *service.AccountService, posts *service.PostService) *UserHandler {
+	return &UserHandler{accounts: accounts, posts: posts}
+}
+
+// GetMe 获取当前用户的完整资料
+func (h *UserHandler) GetMe(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
+		return
+	}
+
+	user, err := h.accounts.Get(c.Request.Context(), userID)
+	if respondAccountError(c, err, "Failed to fetch user") {
+		return
+	}
+
+	response.OK(c, accountOf(user))
+}
+
+// UpdateMe 修改当前用户的显示名称、简介和头像
+func (h *UserHandler) UpdateMe(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	user, err := h.accounts.UpdateProfile(c.Request.Context(), userID, service.ProfileInput{
+		DisplayName: req.DisplayName,
+		Bio:         req.Bio,
+		AvatarURL:   req.AvatarURL,
+	})
+	if respondAccountError(c, err, "Failed to update profile") {
+		return
+	}
+
+	requestLogger(c).Info("profile updated")
+	response.OK(c, accountOf(user))
+}
+
+// ChangePassword 校验当前密码后修改密码，其他登录会话随之失效，响应中返回当前会话的新令牌
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	session, ok := sessionFrom(c)
+	if !ok {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	pair, err := h.accounts.ChangePassword(c.Request.Context(), session, req.OldPassword, req.NewPassword)
+	if respondAccountError(c, err, "Failed to change password") {
+		return
+	}
+
+	requestLogger(c).Info("password changed")
+	response.OK(c, gin.H{
+		"token":                    pair.AccessToken,
+		"expires_at":               pair.AccessTokenExpiresAt,
+		"refresh_token":            pair.RefreshToken,
+		"refresh_token_expires_at": pair.RefreshTokenExpiresAt,
+	})
+}
+
+// DeleteMe 校验密码后注销当前用户的账号
+func (h *UserHandler) DeleteMe(c *gin.Context) {
+	session, ok := sessionFrom(c)
+	if !ok {
+		response.Error(c, apierror.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	err := h.accounts.Delete(c.Request.Context(), session, req.Password, req.DeleteContent)
+	if respondAccountError(c, err, "Failed to delete account") {
+		return
+	}
+
+	requestLogger(c).Info("account deleted", "delete_content", req.DeleteContent)
+	response.NoContent(c)
+}
+
+// GetUser 获取用户的公开资料及其已发布的文章，文章的分页和排序参数与文章列表相同
+func (h *UserHandler) GetUser(c *gin.Context) {
+	userID, ok := parseID(c, "id")
+	if !ok {
+		response.Error(c, apierror.BadRequest("Invalid user ID"))
+		return
+	}
+
+	var query ListPostsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+	in, ok := listPostsInput(c, query)
+	if !ok {
+		return
+	}
+
+	user, err := h.accounts.Get(c.Request.Context(), userID)
+	if respondAccountError(c, err, "Failed to fetch user") {
+		return
+	}
+
+	in.AuthorID = user.ID
+	in.Viewer = actorFrom(c).ID
+	page, err := h.posts.List(c.Request.Context(), in)
+	if err != nil {
+		respondPostPage(c, page, err)
+		return
+	}
+
+	data := gin.H{
+		"user":  publicProfile(user),
+		"posts": page.Posts,
+	}
+	response.List(c, data, postPageMeta(page))
+}
+
+// account 用户本人和管理员可见的账号信息，在 User 的公开字段之外包含邮箱
+type account struct {
+	*models.User
+	Email string `json:"email"`
+}
+
+// accountOf 返回 user 的账号信息
+func accountOf(user *models.User) account {
+	return account{User: user, Email: user.Email}
+}
+
+// publicProfile 用户的公开资料，不包含邮箱等私人信息
+func publicProfile(user *models.User) gin.H {
+	return gin.H{
+		"id":           user.ID,
+		"username":     user.Username,
+		"display_name": user.DisplayName,
+		"bio":          user.Bio,
+		"avatar_url":   user.AvatarURL,
+		"role":         user.Role,
+		"created_at":   user.CreatedAt,
+	}
+}
+
+// sessionFrom 从上下文获取当前登录会话，未登录时返回 false
+func sessionFrom(c *gin.Context) (service.Session, bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return service.Session{}, false
+	}
+	jti, expiresAt := middleware.GetTokenInfo(c)
+	return service.Session{UserID: userID, JTI: jti, AccessExpiresAt: expiresAt}, true
+}
+
+// respondAccountError 把账号接口的错误写成响应，其他错误按 message 作为内部错误输出。
+// err 为空时返回 false，否则返回 true
+func respondAccountError(c *gin.Context, err error, message string) bool {
+	var fieldErr *service.FieldError
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, service.ErrUserNotFound):
+		response.Error(c, apierror.NotFound("User not found"))
+	case errors.As(err, &fieldErr):
+		response.Error(c, apierror.Invalid(fieldErr.Field, fieldErr.Rule, fieldErr.Message))
+	case errors.Is(err, service.ErrWrongPassword):
+		response.Error(c, apierror.New(http.StatusForbidden, apierror.CodeWrongPassword, "Current password is incorrect"))
+	case errors.Is(err, service.ErrAdminAccount):
+		response.Error(c, apierror.Forbidden("Admins cannot delete their own account, ask another admin to change your role first"))
+	default:
+		response.Error(c, apierror.Internal(message, err))
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+// Package jwtkeys 加载 JWT 签名密钥并按令牌头部的 kid 选择校验密钥。
+// 同时配置多个密钥即可轮换：新令牌用签发密钥签名，旧密钥保留到它签发的令牌全部过期后再删除。
+package jwtkeys
+
+import (
+	"blog/config"
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key 一个签名密钥
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// SignKey 签名用的密钥，只配置了公钥时为 nil
+	SignKey interface{}
+	// VerifyKey 校验用的密钥
+	VerifyKey interface{}
+}
+
+// Set 一组签名密钥
+type Set struct {
+	keys    map[string]*Key
+	signing *Key
+	methods []string
+}
+
+// Load 根据配置加载所有密钥，读取或解析密钥文件失败时返回错误
+func Load(cfg config.JWTConfig) (*Set, error) {
+	set := &Set{keys: make(map[string]*Key)}
+	seenMethods := make(map[string]bool)
+	for _, kc := range cfg.AllKeys() {
+		key, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", kc.ID, err)
+		}
+		set.keys[key.ID] = key
+		if alg := key.Method.Alg(); !seenMethods[alg] {
+			seenMethods[alg] = true
+			set.methods = append(set.methods, alg)
+		}
+	}
+
+	signing, ok := set.keys[cfg.SigningKeyID()]
+	if !ok || signing.SignKey == nil {
+		return nil, fmt.Errorf("jwt signing key %q not found or has no private key", cfg.SigningKeyID())
+	}
+	set.signing = signing
+	return set, nil
+}
+
+// loadKey 按算法读取密钥材料，配置了私钥时从私钥推导公钥
+func loadKey(kc config.JWTKeyConfig) (*Key, error) {
+	switch kc.Algorithm {
+	case config.JWTHS256:
+		secret := []byte(kc.Secret)
+		return &Key{ID: kc.ID, Method: jwt.SigningMethodHS256, SignKey: secret, VerifyKey: secret}, nil
+
+	case config.JWTRS256:
+		key := &Key{ID: kc.ID, Method: jwt.SigningMethodRS256}
+		if kc.PrivateKeyFile != "" {
+			priv, err := readPEM(kc.PrivateKeyFile, jwt.ParseRSAPrivateKeyFromPEM)
+			if err != nil {
+				return nil, err
+			}
+			key.SignKey, key.VerifyKey = priv, &priv.PublicKey
+		}
+		if kc.PublicKeyFile != "" {
+			pub, err := readPEM(kc.PublicKeyFile, jwt.ParseRSAPublicKeyFromPEM)
+			if err != nil {
+				return nil, err
+			}
+			key.VerifyKey = pub
+		}
+		return key, nil
+
+	case config.JWTEdDSA:
+		key := &Key{ID: kc.ID, Method: jwt.SigningMethodEdDSA}
+		if kc.PrivateKeyFile != "" {
+			priv, err := readPEM(kc.PrivateKeyFile, jwt.ParseEdPrivateKeyFromPEM)
+			if err != nil {
+				return nil, err
+			}
+			signer, ok := priv.(interface{ Public() crypto.PublicKey })
+			if !ok {
+				return nil, errors.New("unsupported EdDSA private key")
+			}
+			key.SignKey, key.VerifyKey = priv, signer.Public()
+		}
+		if kc.PublicKeyFile != "" {
+			pub, err := readPEM(kc.PublicKeyFile, jwt.ParseEdPublicKeyFromPEM)
+			if err != nil {
+				return nil, err
+			}
+			key.VerifyKey = pub
+		}
+		return key, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", kc.Algorithm)
+	}
+}
+
+// readPEM 读取 PEM 文件并用 parse 解析
+func readPEM[T any](path string, parse func([]byte) (T, error)) (T, error) {
+	var zero T
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return zero, err
+	}
+	key, err := parse(data)
+	if err != nil {
+		return zero, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// Signing 返回签发新令牌使用的密钥
+func (s *Set) Signing() *Key {
+	return s.signing
+}
+
+// Methods 返回所有密钥使用的算法，用于限制令牌可以声明的算法
+func (s *Set) Methods() []string {
+	return s.methods
+}
+
+// Keyfunc 按令牌头部的 kid 返回校验密钥，kid 缺失、未知或与令牌声明的算法不一致时返回错误
+func (s *Set) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("key %q does not use %s", kid, token.Method.Alg())
+	}
+	return key.VerifyKey, nil
+}
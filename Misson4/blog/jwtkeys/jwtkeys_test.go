@@ -0,0 +1,114 @@
+package jwtkeys
+
+import (
+	"blog/config"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// writeEdKey 生成一个 Ed25519 私钥并写入临时 PEM 文件
+func writeEdKey(t *testing.T) string {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "ed25519.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestKeyfunc(t *testing.T) {
+	set, err := Load(config.JWTConfig{
+		Secret: testSecret,
+		Keys:   []config.JWTKeyConfig{{ID: "ed", Algorithm: config.JWTEdDSA, PrivateKeyFile: writeEdKey(t)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := set.Methods(), []string{"EdDSA", "HS256"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Methods() = %v, want %v", got, want)
+	}
+	// 未指定 signing_key 时使用第一个密钥签发
+	if set.Signing().ID != "ed" {
+		t.Errorf("Signing().ID = %q, want %q", set.Signing().ID, "ed")
+	}
+
+	tests := []struct {
+		name    string
+		method  jwt.SigningMethod
+		kid     interface{}
+		wantErr bool
+	}{
+		{"hs256 key", jwt.SigningMethodHS256, config.DefaultJWTKeyID, false},
+		{"eddsa key", jwt.SigningMethodEdDSA, "ed", false},
+		{"missing kid", jwt.SigningMethodHS256, nil, true},
+		{"non-string kid", jwt.SigningMethodHS256, 1, true},
+		{"unknown kid", jwt.SigningMethodHS256, "rotated-out", true},
+		{"hmac token for eddsa key", jwt.SigningMethodHS256, "ed", true},
+		{"eddsa token for hmac key", jwt.SigningMethodEdDSA, config.DefaultJWTKeyID, true},
+		{"other hmac size", jwt.SigningMethodHS384, config.DefaultJWTKeyID, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := jwt.New(tt.method)
+			if tt.kid != nil {
+				token.Header["kid"] = tt.kid
+			}
+			key, err := set.Keyfunc(token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Keyfunc() = %v, want error", key)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key == nil {
+				t.Fatal("Keyfunc() returned a nil key")
+			}
+		})
+	}
+}
+
+func TestKeyfuncVerifiesSignedToken(t *testing.T) {
+	set, err := Load(config.JWTConfig{Secret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signing := set.Signing()
+	token := jwt.NewWithClaims(signing.Method, jwt.MapClaims{"sub": "1"})
+	token.Header["kid"] = signing.ID
+	signed, err := token.SignedString(signing.SignKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(signed, set.Keyfunc, jwt.WithValidMethods(set.Methods())); err != nil {
+		t.Fatalf("Parse() = %v", err)
+	}
+
+	other, err := Load(config.JWTConfig{Secret: "fedcba9876543210fedcba9876543210"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(signed, other.Keyfunc, jwt.WithValidMethods(other.Methods())); err == nil {
+		t.Error("token signed with another secret was accepted")
+	}
+}
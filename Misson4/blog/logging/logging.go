@@ -0,0 +1,59 @@
+// Package logging 创建结构化日志（log/slog），并通过 context 传递请求级别的 logger：
+// 请求开始时把带有请求 ID 的 logger 放入 context，之后 handler、service 和数据库查询
+// 用 FromContext 取出记录日志，同一请求的日志都能按 request_id 关联起来。
+package logging
+
+import (
+	"blog/config"
+	"context"
+	"io"
+	"log/slog"
+)
+
+// contextKey logger 在 context 中的键
+type contextKey struct{}
+
+// New 按配置创建写入 w 的 logger
+func New(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: Level(cfg.Level)}
+
+	var h slog.Handler
+	if cfg.Format == config.LogFormatText {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(h)
+}
+
+// Level 将配置中的日志级别映射为 slog 的级别
+func Level(level string) slog.Level {
+	switch level {
+	case config.LogLevelDebug:
+		return slog.LevelDebug
+	case config.LogLevelWarn:
+		return slog.LevelWarn
+	case config.LogLevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithContext 返回带有 logger 的 context
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext 返回 context 中的 logger，没有时返回默认 logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// With 在 context 中的 logger 上附加属性，返回新的 context
+func With(ctx context.Context, args ...any) context.Context {
+	return WithContext(ctx, FromContext(ctx).With(args...))
+}
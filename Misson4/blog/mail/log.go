@@ -0,0 +1,28 @@
+package mail
+
+import (
+	"blog/logging"
+	"context"
+	"net/mail"
+	"time"
+)
+
+// Log 把邮件完整写入日志而不真正发送
+type Log struct {
+	from *mail.Address
+}
+
+// NewLog 创建 Log
+func NewLog(from *mail.Address) *Log {
+	return &Log{from: from}
+}
+
+// Send 把邮件写入日志
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	data, err := format(l.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "mail", "to", msg.To, "message", string(data))
+	return nil
+}
@@ -0,0 +1,84 @@
+// Package mail 发送通知邮件。默认的 Maildir 实现只把邮件写入本地目录，
+// 开发和测试时不需要邮件服务器，直接查看生成的文件即可。
+package mail
+
+import (
+	"blog/config"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Message 一封纯文本邮件
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 发送邮件
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New 根据配置创建 Mailer
+func New(cfg config.MailConfig) (Mailer, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("mail: invalid from address %q: %w", cfg.From, err)
+	}
+
+	switch cfg.Driver {
+	case config.MailMaildir:
+		return NewMaildir(cfg.Maildir.Dir, from)
+	case config.MailLog:
+		return NewLog(from), nil
+	case config.MailSMTP:
+		return NewSMTP(cfg.SMTP, from), nil
+	default:
+		return nil, fmt.Errorf("mail: unknown driver %q", cfg.Driver)
+	}
+}
+
+// format 生成 RFC 5322 格式的邮件，主题按 RFC 2047 编码以支持中文
+func format(from *mail.Address, msg Message, now time.Time) ([]byte, error) {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("mail: invalid recipient %q: %w", msg.To, err)
+	}
+	id, err := messageID(from)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: %s\r\n", id)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes(), nil
+}
+
+// messageID 生成 <随机值@发件人域名> 形式的 Message-ID
+func messageID(from *mail.Address) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	domain := "localhost"
+	if i := strings.LastIndexByte(from.Address, '@'); i >= 0 {
+		domain = from.Address[i+1:]
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">", nil
+}
@@ -0,0 +1,55 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Maildir 把邮件写入 Maildir 格式的目录：先写到 tmp/，完成后移动到 new/，
+// 可以直接用邮件客户端打开，也可以在测试中读取 new/ 下的文件
+type Maildir struct {
+	dir  string
+	from *mail.Address
+	host string
+}
+
+// NewMaildir 创建 Maildir，不存在时创建 dir 及其 tmp、new、cur 子目录
+func NewMaildir(dir string, from *mail.Address) (*Maildir, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("mail: create maildir: %w", err)
+		}
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	// 文件名中不能出现 / 和 :
+	host = strings.NewReplacer("/", "_", ":", "_").Replace(host)
+	return &Maildir{dir: dir, from: from, host: host}, nil
+}
+
+// Send 写入一封邮件
+func (m *Maildir) Send(ctx context.Context, msg Message) error {
+	now := time.Now()
+	data, err := format(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d.%d_%d.%s", now.Unix(), now.UnixNano(), os.Getpid(), m.host)
+	tmp := filepath.Join(m.dir, "tmp", name)
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("mail: write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, filepath.Join(m.dir, "new", name)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("mail: deliver %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package mail
+
+import (
+	"blog/config"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// smtpTimeout 连接 SMTP 服务器并发送一封邮件的超时时间
+const smtpTimeout = 30 * time.Second
+
+// SMTP 通过 SMTP 服务器发送邮件。端口 465 直接使用 TLS 连接，
+// 其他端口在服务器支持时升级为 STARTTLS；配置了用户名时使用 PLAIN 认证
+type SMTP struct {
+	cfg  config.SMTPConfig
+	from *mail.Address
+}
+
+// NewSMTP 创建 SMTP
+func NewSMTP(cfg config.SMTPConfig, from *mail.Address) *SMTP {
+	return &SMTP{cfg: cfg, from: from}
+}
+
+// Send 发送一封邮件
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	data, err := format(s.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("mail: invalid recipient %q: %w", msg.To, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	client, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("mail: connect to smtp server: %w", err)
+	}
+	defer client.Close()
+
+	if err := s.send(client, to.Address, data); err != nil {
+		return fmt.Errorf("mail: send to %s: %w", to.Address, err)
+	}
+	return client.Quit()
+}
+
+// dial 连接 SMTP 服务器，连接的截止时间与 ctx 一致
+func (s *SMTP) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if s.cfg.Port == 465 {
+		conn = tls.Client(conn, &tls.Config{ServerName: s.cfg.Host})
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+func (s *SMTP) send(client *smtp.Client, to string, data []byte) error {
+	if ok, _ := client.Extension("STARTTLS"); ok && s.cfg.Port != 465 {
+		if err := client.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		auth := smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Close()
+}
@@ -1,51 +1,425 @@
 package main
 
 import (
+	"blog/apierror"
+	"blog/config"
 	"blog/database"
 	"blog/handlers"
+	"blog/logging"
+	"blog/mail"
 	"blog/middleware"
-	"log"
+	"blog/models"
+	"blog/ratelimit"
+	"blog/repository"
+	"blog/search"
+	"blog/service"
+	"blog/signedtoken"
+	"blog/storage"
+	"blog/tracing"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	configPath := flag.String("config", "", "配置文件路径（.yaml/.yml/.toml），默认读取 BLOG_CONFIG")
+	flag.Usage = usage
+	flag.Parse()
 
-	database.InitDB()
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	// 之后的日志（包括标准库 log 的输出）都使用结构化日志
+	slog.SetDefault(logging.New(cfg.Log, os.Stderr))
+
+	if cfg.Log.Level != config.LogLevelDebug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	database.InitDB(cfg.Database, cfg.Log.Level)
+
+	switch cmd := flag.Arg(0); cmd {
+	case "", "serve":
+		serve(cfg)
+	case "migrate":
+		if err := runMigrate(flag.Args()[1:]); err != nil {
+			fatal("command failed", err)
+		}
+	case "user":
+		if err := runUser(flag.Args()[1:]); err != nil {
+			fatal("command failed", err)
+		}
+	case "counters":
+		if err := runCounters(flag.Args()[1:]); err != nil {
+			fatal("command failed", err)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", cmd)
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: blog [-config file] [command]
+
+Commands:
+  serve                 启动 HTTP 服务（默认）
+  migrate up            执行所有未执行的迁移
+  migrate down [n]      回滚最近 n 个迁移（默认 1）
+  migrate status        查看迁移状态
+  user set-role <username> <role>
+                        修改用户角色（reader、author、editor、admin）
+  counters reconcile    根据文章、评论和点赞记录重新计算文章数、评论数和点赞数
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
+
+// shutdownTimeout 收到退出信号后等待进行中的请求完成、写入浏览量和导出 span 的最长时间
+const shutdownTimeout = 15 * time.Second
+
+// serve 启动 HTTP 服务，收到 SIGINT 或 SIGTERM 后优雅退出：
+// 停止接收新请求并等待进行中的请求完成，停止后台任务，写入内存中的浏览量，最后导出尚未发送的 span
+func serve(cfg *config.Config) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.Database.AutoMigrate {
+		if _, err := database.MigrateUp(database.DB); err != nil {
+			fatal("failed to migrate database", err)
+		}
+	}
+
+	var shutdownTracing func(context.Context) error
+	if cfg.Tracing.Enabled {
+		var err error
+		shutdownTracing, err = tracing.Setup(context.Background(), cfg.Tracing)
+		if err != nil {
+			fatal("failed to initialize tracing", err)
+		}
+		if err := database.DB.Use(tracing.GormPlugin{}); err != nil {
+			fatal("failed to initialize tracing", err)
+		}
+	}
+
+	if err := middleware.SetupJWT(cfg.JWT); err != nil {
+		fatal("failed to load jwt keys", err)
+	}
 
-	r := gin.Default()
+	// 组装仓储、服务和 handler
+	userRepo := repository.NewUserRepository(database.DB)
+	postRepo := repository.NewPostRepository(database.DB)
+	commentRepo := repository.NewCommentRepository(database.DB)
+	tokenRepo := repository.NewTokenRepository(database.DB)
+	tagRepo := repository.NewTagRepository(database.DB)
+	categoryRepo := repository.NewCategoryRepository(database.DB)
+	reactionRepo := repository.NewReactionRepository(database.DB)
 
-	// 公开接口 - 不需要认证
-	api := r.Group("/api")
+	// 后台任务在 ctx 取消时退出，退出前通过 tasks 等待它们结束
+	var tasks sync.WaitGroup
+	runTask := func(task func(context.Context)) {
+		tasks.Add(1)
+		go func() {
+			defer tasks.Done()
+			task(ctx)
+		}()
+	}
+
+	tokenService := service.NewTokenService(tokenRepo, userRepo, middleware.GenerateToken, cfg.JWT.RefreshExpiry.Std())
+	runTask(func(ctx context.Context) { purgeExpiredTokens(ctx, tokenService) })
+
+	searchEngine, err := search.New(context.Background(), cfg.Database.Driver, database.DB)
+	if err != nil {
+		fatal("failed to build search index", err)
+	}
+
+	store, err := storage.New(context.Background(), cfg.Storage)
+	if err != nil {
+		fatal("failed to initialize storage", err)
+	}
+
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		fatal("failed to initialize mailer", err)
+	}
+	mailKey := []byte(cfg.Mail.TokenSecret)
+	if len(mailKey) == 0 {
+		mailKey = signedtoken.DeriveKey([]byte(cfg.JWT.Secret), "blog mail token")
+	}
+	emailService := service.NewEmailService(userRepo, tokenService, mailer, signedtoken.New(mailKey), service.EmailOptions{
+		BaseURL:      cfg.Mail.BaseURL,
+		VerifyExpiry: cfg.Mail.VerifyExpiry.Std(),
+		ResetExpiry:  cfg.Mail.ResetExpiry.Std(),
+	})
+
+	limitStore, err := ratelimit.New(context.Background(), cfg.RateLimit)
+	if err != nil {
+		fatal("failed to initialize rate limit store", err)
+	}
+	lockout := ratelimit.NewLockout(limitStore, ratelimit.LockoutPolicy{
+		MaxFailures: cfg.RateLimit.Lockout.MaxFailures,
+		BaseDelay:   cfg.RateLimit.Lockout.BaseDelay.Std(),
+		MaxDelay:    cfg.RateLimit.Lockout.MaxDelay.Std(),
+		Window:      cfg.RateLimit.Lockout.Window.Std(),
+	})
+	// rateLimit 按配置创建限流中间件，未启用限流时直接放行
+	rateLimit := func(name string, limit config.LimitConfig, key middleware.KeyFunc) gin.HandlerFunc {
+		if !cfg.RateLimit.Enabled {
+			return func(c *gin.Context) { c.Next() }
+		}
+		return middleware.RateLimit(limitStore, name, ratelimit.Limit{Rate: limit.Rate, Burst: limit.Burst}, key)
+	}
+	authLimit := rateLimit("auth", cfg.RateLimit.Auth, middleware.ByIP)
+	// viewer 公开接口识别当前访问者，未登录或令牌无效时按匿名访问
+	viewer := middleware.OptionalAuth(tokenService)
+
+	userService := service.NewUserService(userRepo)
+	authHandler := handlers.NewAuthHandler(userService, tokenService, emailService, lockout)
+	emailHandler := handlers.NewEmailHandler(emailService, lockout)
+	adminHandler := handlers.NewAdminHandler(userService)
+	viewCounter := service.NewViewCounter(postRepo)
+	runTask(func(ctx context.Context) { flushViews(ctx, viewCounter) })
+	postService := service.NewPostService(postRepo, tagRepo, categoryRepo, reactionRepo, searchEngine, viewCounter)
+	runTask(func(ctx context.Context) { publishScheduledPosts(ctx, postService) })
+
+	postHandler := handlers.NewPostHandler(postService)
+	tagHandler := handlers.NewTagHandler(service.NewTagService(tagRepo, categoryRepo))
+	commentHandler := handlers.NewCommentHandler(service.NewCommentService(commentRepo, postRepo, searchEngine))
+	searchHandler := handlers.NewSearchHandler(service.NewSearchService(searchEngine))
+	attachmentService := service.NewAttachmentService(repository.NewAttachmentRepository(database.DB), postRepo, store, cfg.Storage.MaxUploadSize)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
+	userHandler := handlers.NewUserHandler(service.NewAccountService(userRepo, tokenService, searchEngine), postService)
+	reactionHandler := handlers.NewReactionHandler(service.NewReactionService(reactionRepo, postRepo))
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("invalid trusted proxies", err)
+	}
+	// 所有响应带上请求 ID，每个请求记录访问日志，错误统一由 ErrorHandler 输出
+	apierror.RegisterFieldNames()
+	r.Use(middleware.RequestID())
+	if cfg.Tracing.Enabled {
+		r.Use(middleware.Tracing())
+	}
+	r.Use(middleware.Logger(slog.Default()), middleware.ErrorHandler())
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(middleware.NoRoute)
+	r.NoMethod(middleware.NoMethod)
+
+	// 本地存储且访问地址是本服务的路径时，直接提供上传的文件
+	if local, ok := store.(*storage.Local); ok && strings.HasPrefix(cfg.Storage.Local.BaseURL, "/") {
+		uploads := r.Group(cfg.Storage.Local.BaseURL, func(c *gin.Context) {
+			c.Header("X-Content-Type-Options", "nosniff")
+		})
+		uploads.Static("/", local.Dir())
+	}
+
+	// 公开接口 - 不需要认证，每个 IP 的请求频率受限
+	api := r.Group("/api", rateLimit("ip", cfg.RateLimit.IP, middleware.ByIP))
 	{
-		// 用户认证
-		api.POST("/register", handlers.Register)
-		api.POST("/login", handlers.Login)
+		// 用户认证，登录、注册等接口使用更严格的限制
+		api.POST("/register", authLimit, authHandler.Register)
+		api.POST("/login", authLimit, authHandler.Login)
+		api.POST("/token/refresh", authLimit, authHandler.Refresh)
+
+		// 邮箱验证和找回密码
+		api.POST("/email/verify", authLimit, emailHandler.VerifyEmail)
+		api.POST("/password/forgot", authLimit, emailHandler.ForgotPassword)
+		api.POST("/password/reset", authLimit, emailHandler.ResetPassword)
+
+		// 用户公开资料及其文章，登录用户可以看到自己是否已点赞、收藏
+		api.GET("/users/:id", viewer, userHandler.GetUser)
+
+		// 文章公开接口，作者登录后可以查看自己未发布的文章
+		api.GET("/posts", viewer, postHandler.GetPosts)
+		api.GET("/posts/:id", viewer, postHandler.GetPost)
+		api.GET("/posts/by-slug/:slug", viewer, postHandler.GetPostBySlug)
 
-		// 文章公开接口
-		api.GET("/posts", handlers.GetPosts)
-		api.GET("/posts/:id", handlers.GetPost)
+		// 文章附件
+		api.GET("/posts/:id/attachments", attachmentHandler.ListPostAttachments)
 
 		// 评论公开接口（使用 :id 作为 postId）
-		api.GET("/posts/:id/comments", handlers.GetComments)
+		api.GET("/posts/:id/comments", commentHandler.GetComments)
+
+		// 标签和分类
+		api.GET("/tags", tagHandler.ListTags)
+		api.GET("/categories", tagHandler.ListCategories)
+
+		// 搜索
+		api.GET("/search", searchHandler.Search)
 	}
 
-	// 需要认证的接口
+	// 需要认证的接口，每个用户的请求频率受限
 	auth := api.Group("")
-	auth.Use(middleware.AuthMiddleware())
+	auth.Use(middleware.AuthMiddleware(tokenService), rateLimit("user", cfg.RateLimit.User, middleware.ByUser))
 	{
+		// 用户认证
+		auth.POST("/logout", authHandler.Logout)
+
+		// 个人资料和账号
+		auth.GET("/me", userHandler.GetMe)
+		auth.PATCH("/me", userHandler.UpdateMe)
+		auth.PUT("/me/password", authLimit, userHandler.ChangePassword)
+		auth.DELETE("/me", authLimit, userHandler.DeleteMe)
+		auth.POST("/me/email/verification", authLimit, emailHandler.ResendVerification)
+
+		// 当前用户的文章，包括草稿、定时和已归档的文章
+		auth.GET("/me/posts", postHandler.GetMyPosts)
+		auth.GET("/me/bookmarks", postHandler.GetMyBookmarks)
+
 		// 文章管理
-		auth.POST("/posts", handlers.CreatePost)
-		auth.PUT("/posts/:id", handlers.UpdatePost)
-		auth.DELETE("/posts/:id", handlers.DeletePost)
+		auth.POST("/posts", middleware.RequirePermission(models.PermPostCreate), postHandler.CreatePost)
+		auth.PUT("/posts/:id", postHandler.UpdatePost)
+		auth.DELETE("/posts/:id", postHandler.DeletePost)
+		auth.POST("/posts/:id/tags", postHandler.AttachTags)
+		auth.DELETE("/posts/:id/tags/:tag", postHandler.DetachTag)
+
+		// 点赞和收藏，重复调用结果相同
+		auth.PUT("/posts/:id/like", reactionHandler.Like)
+		auth.DELETE("/posts/:id/like", reactionHandler.Unlike)
+		auth.PUT("/posts/:id/bookmark", reactionHandler.Bookmark)
+		auth.DELETE("/posts/:id/bookmark", reactionHandler.Unbookmark)
+
+		// 文章历史版本，作者和 editor、admin 可以查看和恢复
+		auth.GET("/posts/:id/revisions", postHandler.ListRevisions)
+		auth.GET("/posts/:id/revisions/diff", postHandler.DiffRevisions)
+		auth.GET("/posts/:id/revisions/:version", postHandler.GetRevision)
+		auth.POST("/posts/:id/revisions/:version/restore", postHandler.RestoreRevision)
+
+		// 图片和附件
+		auth.POST("/uploads", middleware.RequirePermission(models.PermPostCreate), attachmentHandler.Upload)
+		auth.POST("/posts/:id/attachments", attachmentHandler.UploadToPost)
+		auth.PATCH("/attachments/:id", attachmentHandler.LinkAttachment)
+		auth.DELETE("/attachments/:id", attachmentHandler.DeleteAttachment)
 
 		// 评论管理（使用 :id 作为 postId）
-		auth.POST("/posts/:id/comments", handlers.CreateComment)
+		auth.POST("/posts/:id/comments", middleware.RequirePermission(models.PermCommentCreate), commentHandler.CreateComment)
+		auth.PUT("/comments/:id", commentHandler.UpdateComment)
+		auth.DELETE("/comments/:id", commentHandler.DeleteComment)
+	}
+
+	// 管理接口，按权限控制访问
+	admin := auth.Group("/admin")
+	{
+		// 用户管理
+		admin.GET("/users", middleware.RequirePermission(models.PermUserManage), adminHandler.ListUsers)
+		admin.PATCH("/users/:id/role", middleware.RequirePermission(models.PermUserManage), adminHandler.UpdateUserRole)
+
+		// 内容审核，可以处理任意用户的文章和评论
+		admin.PUT("/posts/:id", middleware.RequirePermission(models.PermPostModerate), postHandler.UpdatePost)
+		admin.DELETE("/posts/:id", middleware.RequirePermission(models.PermPostModerate), postHandler.DeletePost)
+		admin.DELETE("/comments/:id", middleware.RequirePermission(models.PermCommentModerate), commentHandler.DeleteComment)
+		admin.DELETE("/attachments/:id", middleware.RequirePermission(models.PermPostModerate), attachmentHandler.DeleteAttachment)
+
+		// 分类管理
+		admin.POST("/categories", middleware.RequirePermission(models.PermCategoryManage), tagHandler.CreateCategory)
 	}
 
 	// 启动服务器
-	log.Println("Server starting on :8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Failed to start server: ", err)
+	srv := &http.Server{Addr: cfg.Server.Addr, Handler: r}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ListenAndServe() }()
+	slog.Info("server starting", "addr", cfg.Server.Addr)
+
+	select {
+	case err := <-serveErr:
+		fatal("failed to start server", err)
+	case <-ctx.Done():
+	}
+	// 恢复默认的信号处理，退出过程中再次收到信号时立即结束
+	stop()
+	slog.Info("server shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("server shutdown failed", "error", err)
+	}
+	tasks.Wait()
+	if err := viewCounter.Flush(shutdownCtx); err != nil {
+		slog.Error("flush post views failed", "error", err)
+	}
+	if shutdownTracing != nil {
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("tracing shutdown failed", "error", err)
+		}
 	}
+	slog.Info("server stopped")
+}
+
+// purgeExpiredTokens 定期清理过期的刷新令牌和访问令牌黑名单，ctx 取消时返回
+func purgeExpiredTokens(ctx context.Context, tokens *service.TokenService) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := tokens.PurgeExpired(context.Background()); err != nil {
+			slog.Error("purge expired tokens failed", "error", err)
+		}
+	}
+}
+
+// flushViews 每 30 秒把内存中累计的浏览量写入数据库，ctx 取消时返回，剩余的浏览量由调用方最后写入
+func flushViews(ctx context.Context, views *service.ViewCounter) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := views.Flush(context.Background()); err != nil {
+			slog.Error("flush post views failed", "error", err)
+		}
+	}
+}
+
+// publishScheduledPosts 每分钟发布一次到期的定时文章，ctx 取消时返回
+func publishScheduledPosts(ctx context.Context, posts *service.PostService) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n, err := posts.PublishDue(context.Background())
+		if err != nil {
+			slog.Error("publish scheduled posts failed", "error", err)
+			continue
+		}
+		if n > 0 {
+			slog.Info("scheduled posts published", "count", n)
+		}
+	}
+}
+
+// fatal 记录错误日志后退出
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,79 @@
+// Package markdown 把文章和评论的 Markdown 渲染为可以直接嵌入页面的安全 HTML，并生成纯文本摘要。
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// DefaultExcerptLength 列表摘要的默认长度（字符）
+const DefaultExcerptLength = 200
+
+var (
+	// md 支持 GitHub 风格的表格、删除线、自动链接和任务列表。
+	// 不开启 WithUnsafe，Markdown 中的原始 HTML 会被丢弃。
+	md = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	// policy 渲染结果的白名单，在 goldmark 之外再做一层过滤：
+	// 只保留常见的排版标签，链接加上 rel="nofollow"，代码块只允许 language-* 的 class，
+	// input 只允许任务列表的只读复选框
+	policy = func() *bluemonday.Policy {
+		p := bluemonday.UGCPolicy()
+		p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w-]+$`)).OnElements("code")
+		p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+		p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^$`)).OnElements("input")
+		return p
+	}()
+
+	// inputTag 匹配所有 input 标签；taskCheckbox 是 goldmark 任务列表输出的复选框，
+	// bluemonday 不能要求属性必须存在，因此先去掉其他形式的 input，避免留下可编辑的输入框
+	inputTag     = regexp.MustCompile(`<input\b[^>]*>`)
+	taskCheckbox = regexp.MustCompile(`^<input (?:checked="" )?disabled="" type="checkbox"\s*/?>$`)
+
+	// strip 去掉全部标签，用于生成纯文本
+	strip = bluemonday.StrictPolicy()
+
+	blockTag = regexp.MustCompile(`</?(?:p|h[1-6]|li|ul|ol|blockquote|pre|table|tr|th|td|hr|br)\b`)
+)
+
+// Render 把 Markdown 渲染为经过白名单过滤的 HTML
+func Render(src string) string {
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(src), &buf); err != nil {
+		// goldmark 写入 bytes.Buffer 不会失败，这里只做兜底：按纯文本转义输出
+		return "<p>" + html.EscapeString(src) + "</p>"
+	}
+	return sanitize(buf.String())
+}
+
+// sanitize 按白名单过滤 goldmark 输出的 HTML
+func sanitize(rendered string) string {
+	rendered = inputTag.ReplaceAllStringFunc(rendered, func(tag string) string {
+		if taskCheckbox.MatchString(tag) {
+			return tag
+		}
+		return ""
+	})
+	return policy.Sanitize(rendered)
+}
+
+// Excerpt 从渲染后的 HTML 中提取纯文本，合并空白后截取前 n 个字符，被截断时以省略号结尾。
+// 返回值是未转义的纯文本，嵌入 HTML 时需要调用方转义。
+func Excerpt(renderedHTML string, n int) string {
+	// 在块级标签前补空格，避免相邻段落、列表项的文字在去掉标签后粘连
+	text := strip.Sanitize(blockTag.ReplaceAllString(renderedHTML, " $0"))
+	text = html.UnescapeString(text)
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:n])) + "…"
+}
@@ -0,0 +1,69 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderTaskList(t *testing.T) {
+	got := Render("- [x] done\n- [ ] todo\n")
+	for _, want := range []string{
+		`<input checked="" disabled="" type="checkbox"> done`,
+		`<input disabled="" type="checkbox"> todo`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Render() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestSanitizeInput(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"checked task", `<input checked="" disabled="" type="checkbox">`, `<input checked="" disabled="" type="checkbox">`},
+		{"open task", `<input disabled="" type="checkbox">`, `<input disabled="" type="checkbox">`},
+		{"text input", `<input type="text" value="x">`, ``},
+		{"enabled checkbox", `<input type="checkbox">`, ``},
+		{"disabled text input", `<input disabled="" type="text">`, ``},
+		{"hidden input", `<input disabled="" type="hidden" name="csrf">`, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitize(tt.in); got != tt.want {
+				t.Errorf("sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderDropsRawHTML(t *testing.T) {
+	got := Render("hello <script>alert(1)</script> <input type=\"text\">")
+	if strings.Contains(got, "<script") || strings.Contains(got, "<input") {
+		t.Errorf("Render() = %q, want raw HTML removed", got)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		n    int
+		want string
+	}{
+		{"short", "<p>Hello <b>world</b></p>", 20, "Hello world"},
+		{"blocks do not stick together", "<p>one</p><p>two</p>", 20, "one two"},
+		{"truncated", "<p>abcdefghij</p>", 5, "abcde…"},
+		{"entities unescaped", "<p>a &amp; b</p>", 20, "a & b"},
+		{"multibyte", "<p>你好世界</p>", 2, "你好…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Excerpt(tt.html, tt.n); got != tt.want {
+				t.Errorf("Excerpt(%q, %d) = %q, want %q", tt.html, tt.n, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,17 @@
 package middleware
 
 import (
+	"blog/apierror"
+	"blog/config"
+	"blog/jwtkeys"
+	"blog/logging"
 	"blog/models"
-	"net/http"
+	"blog/response"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
 	"strings"
 	"time"
 
@@ -10,76 +19,203 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte("your_secret_key_change_in_production")
+var (
+	jwtKeys     *jwtkeys.Set
+	jwtExpiry   time.Duration
+	jwtIssuer   string
+	jwtAudience string
+)
+
+// Claims 访问令牌携带的声明
+type Claims struct {
+	UserID   uint        `json:"id"`
+	Username string      `json:"username"`
+	Role     models.Role `json:"role"`
+	jwt.RegisteredClaims
+}
 
-// GenerateToken 生成 JWT token
-func GenerateToken(user *models.User) (string, error) {
-	claims := jwt.MapClaims{
-		"id":       user.ID,
-		"username": user.Username,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(), // 24小时过期
+// SetupJWT 使用配置加载 JWT 密钥并设置签发者、受众和过期时间，需在启动时调用
+func SetupJWT(cfg config.JWTConfig) error {
+	keys, err := jwtkeys.Load(cfg)
+	if err != nil {
+		return err
 	}
+	jwtKeys = keys
+	jwtExpiry = cfg.Expiry.Std()
+	jwtIssuer = cfg.Issuer
+	jwtAudience = cfg.Audience
+	return nil
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+// TokenVerifier 校验签名之外的令牌状态。注销后的令牌在过期前仍能通过签名校验，需要按 jti 拒绝；
+// 令牌中的角色是签发时的快照，权限按用户当前的角色判断，修改角色后立即生效；
+// 用户注销账号后 exists 为 false，其他设备上已签发的令牌也随之失效
+type TokenVerifier interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	CurrentRole(ctx context.Context, userID uint) (role models.Role, exists bool, err error)
 }
 
-// AuthMiddleware JWT 认证中间件
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-			c.Abort()
-			return
-		}
+// GenerateToken 生成 JWT 访问令牌，返回令牌、jti 和过期时间
+func GenerateToken(user *models.User) (string, string, time.Time, error) {
+	jti, err := newJTI()
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
 
-		// 检查 Bearer 前缀
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
-			return
-		}
+	now := time.Now().Truncate(time.Second)
+	expiresAt := now.Add(jwtExpiry)
+	claims := Claims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    jwtIssuer,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			Audience:  jwt.ClaimStrings{jwtAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        jti,
+		},
+	}
+
+	key := jwtKeys.Signing()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.SignKey)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	return signed, jti, expiresAt, nil
+}
+
+// newJTI 生成随机的令牌 ID
+func newJTI() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// 从上下文取当前用户时的错误
+var (
+	// ErrNoToken 请求没有携带访问令牌
+	ErrNoToken = errors.New("no access token")
+	// ErrInvalidToken 请求携带的访问令牌无效、已过期或已注销
+	ErrInvalidToken = errors.New("invalid access token")
+)
+
+// authenticate 校验 Authorization 头中的 Bearer 令牌，成功时把用户信息写入上下文。
+// 没有令牌时返回 CodeUnauthorized，令牌无效时返回 CodeInvalidToken
+func authenticate(c *gin.Context, verifier TokenVerifier) *apierror.Error {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		return apierror.Unauthorized("Authorization header is required")
+	}
 
-		tokenString := parts[1]
+	// 检查 Bearer 前缀
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return apierror.InvalidToken("Invalid authorization header format")
+	}
+
+	tokenString := parts[1]
 
-		// 解析 token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return jwtSecret, nil
-		}, jwt.WithValidMethods([]string{"HS256"}))
+	// 解析 token，按 kid 选择密钥并校验签发者、受众和有效期
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, jwtKeys.Keyfunc,
+		jwt.WithValidMethods(jwtKeys.Methods()),
+		jwt.WithIssuer(jwtIssuer),
+		jwt.WithAudience(jwtAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil || !token.Valid {
+		return apierror.InvalidToken("Invalid or expired token")
+	}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+	if claims.UserID == 0 || claims.ID == "" {
+		return apierror.InvalidToken("Invalid token claims")
+	}
+
+	// 检查令牌是否已注销
+	jti := claims.ID
+	revoked, err := verifier.IsRevoked(c.Request.Context(), jti)
+	if err != nil {
+		return apierror.Internal("Failed to verify token", err)
+	}
+	if revoked {
+		return apierror.InvalidToken("Token has been revoked")
+	}
+
+	role, exists, err := verifier.CurrentRole(c.Request.Context(), claims.UserID)
+	if err != nil {
+		return apierror.Internal("Failed to verify token", err)
+	}
+	if !exists {
+		return apierror.InvalidToken("User no longer exists")
+	}
+
+	// 将用户信息存储到上下文中
+	c.Set("userID", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", role)
+	c.Set("jti", jti)
+	c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+	// 之后记录的日志都带上用户 ID
+	c.Request = c.Request.WithContext(logging.With(c.Request.Context(), "user_id", claims.UserID))
+	return nil
+}
+
+// AuthMiddleware JWT 认证中间件，拒绝未登录的请求和已加入黑名单的令牌
+func AuthMiddleware(verifier TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiErr := authenticate(c, verifier); apiErr != nil {
+			response.Error(c, apiErr)
 			return
 		}
+		c.Next()
+	}
+}
 
-		// 从 token 中提取用户信息
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
+// OptionalAuth 可选认证中间件，用于公开接口识别当前访问者。
+// 带有效令牌时与 AuthMiddleware 一样写入用户信息；没有令牌或令牌无效时按匿名访问处理，
+// 无效令牌不会被拒绝，但 GetUserID 会返回 ErrInvalidToken，响应头带上 WWW-Authenticate 提示客户端刷新令牌。
+func OptionalAuth(verifier TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiErr := authenticate(c, verifier)
+		switch {
+		case apiErr == nil, apiErr.Code == apierror.CodeUnauthorized:
+		case apiErr.Code == apierror.CodeInvalidToken:
+			c.Set("authError", ErrInvalidToken)
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		default:
+			response.Error(c, apiErr)
 			return
 		}
-
-		// 将用户ID存储到上下文中
-		userID := uint(claims["id"].(float64))
-		c.Set("userID", userID)
-		c.Set("username", claims["username"])
-
 		c.Next()
 	}
 }
 
-// GetUserID 从上下文获取用户ID
-func GetUserID(c *gin.Context) uint {
-	userID, exists := c.Get("userID")
-	if !exists {
-		return 0
+// GetUserID 从上下文获取当前用户ID。没有携带令牌时返回 ErrNoToken，
+// 在 OptionalAuth 之后令牌无效时返回 ErrInvalidToken；AuthMiddleware 之后总是成功
+func GetUserID(c *gin.Context) (uint, error) {
+	if userID, ok := c.Get("userID"); ok {
+		if id, ok := userID.(uint); ok && id != 0 {
+			return id, nil
+		}
+		return 0, ErrInvalidToken
+	}
+	if err, ok := c.Get("authError"); ok {
+		return 0, err.(error)
 	}
-	return userID.(uint)
+	return 0, ErrNoToken
+}
+
+// GetTokenInfo 从上下文获取当前访问令牌的 jti 和过期时间
+func GetTokenInfo(c *gin.Context) (string, time.Time) {
+	jti := c.GetString("jti")
+	expiresAt := c.GetTime("tokenExpiresAt")
+	return jti, expiresAt
 }
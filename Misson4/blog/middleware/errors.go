@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"blog/apierror"
+	"blog/response"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 请求 ID 的请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID 客户端或上游代理传入的请求 ID 格式，不符合时重新生成
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID 为每个请求分配 ID，写入响应头和响应体的 request_id。
+// 请求已带有合法的 X-Request-ID 时沿用，便于和上游代理的日志对应
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err != nil {
+				panic(err)
+			}
+			id = hex.EncodeToString(b)
+		}
+		response.SetRequestID(c, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// ErrorHandler 统一输出错误响应，需放在 RequestID 之后、其他中间件之前。
+// handler 和中间件通过 response.Error 记录错误后返回，这里把最后一个错误转换为 API 错误输出；
+// 不是 *apierror.Error 的错误和 panic 按 500 处理，500 错误的内部原因写入日志，不返回给客户端
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				requestLogger(c).Error("panic recovered", "panic", r, "stack", string(debug.Stack()))
+				writeError(c, apierror.Internal("Internal server error", fmt.Errorf("panic: %v", r)))
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) > 0 {
+			writeError(c, apierror.From(c.Errors.Last().Err))
+		}
+	}
+}
+
+// writeError 输出错误响应，响应已经写出时只记录日志
+func writeError(c *gin.Context, apiErr *apierror.Error) {
+	if apiErr.Status >= http.StatusInternalServerError {
+		requestLogger(c).Error("request failed", "error", apiErr)
+	}
+	if c.Writer.Written() {
+		return
+	}
+	response.WriteError(c, apiErr)
+}
+
+// NoRoute 路由不存在时的响应
+func NoRoute(c *gin.Context) {
+	response.Error(c, apierror.NotFound("Route not found"))
+}
+
+// NoMethod 路由存在但不支持请求方法时的响应
+func NoMethod(c *gin.Context) {
+	response.Error(c, apierror.New(http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed"))
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"blog/logging"
+	"blog/response"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Logger 为每个请求创建带有请求 ID（启用链路追踪时还有 trace_id）的 logger 并放入请求的 context，
+// 请求结束后记录一条访问日志：方法、路由、状态码、耗时和用户 ID。
+// 需放在 RequestID 和 Tracing 之后、ErrorHandler 之前，这样访问日志中的状态码包含错误响应
+func Logger(base *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		logger := base.With("request_id", response.RequestID(c))
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+			logger = logger.With("trace_id", sc.TraceID().String())
+		}
+		c.Request = c.Request.WithContext(logging.WithContext(c.Request.Context(), logger))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []any{
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", milliseconds(time.Since(start)),
+			"client_ip", c.ClientIP(),
+			"bytes", c.Writer.Size(),
+		}
+		if userID, err := GetUserID(c); err == nil {
+			attrs = append(attrs, "user_id", userID)
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		logger.Log(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// milliseconds 以毫秒为单位的耗时，保留三位小数
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// requestLogger 返回请求的 logger
+func requestLogger(c *gin.Context) *slog.Logger {
+	return logging.FromContext(c.Request.Context())
+}
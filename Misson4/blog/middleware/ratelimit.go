@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"blog/apierror"
+	"blog/ratelimit"
+	"blog/response"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc 返回请求的限流维度，例如 "ip:1.2.3.4"
+type KeyFunc func(c *gin.Context) string
+
+// ByIP 按客户端 IP 限流。客户端 IP 只在请求来自可信代理时才取自 X-Forwarded-For
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser 按登录用户限流，未登录时按客户端 IP，需放在 AuthMiddleware 之后
+func ByUser(c *gin.Context) string {
+	if userID, err := GetUserID(c); err == nil {
+		return "user:" + strconv.FormatUint(uint64(userID), 10)
+	}
+	return ByIP(c)
+}
+
+// RateLimit 令牌桶限流中间件，name 区分不同的限流规则。
+// 响应中带有 X-RateLimit-Limit 和 X-RateLimit-Remaining，超出限制时返回 429 和 Retry-After。
+// 存储出错时记录日志并放行，避免限流存储故障导致整个服务不可用。
+func RateLimit(store ratelimit.Store, name string, limit ratelimit.Limit, key KeyFunc) gin.HandlerFunc {
+	limitHeader := strconv.Itoa(limit.Burst)
+	return func(c *gin.Context) {
+		result, err := store.Take(c.Request.Context(), name+":"+key(c), limit)
+		if err != nil {
+			requestLogger(c).Error("rate limit store error", "limit", name, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", limitHeader)
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		if !result.Allowed {
+			SetRetryAfter(c, result.RetryAfter)
+			response.Error(c, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited, "Too many requests, please try again later"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// SetRetryAfter 设置 Retry-After 响应头，向上取整到秒且至少为 1 秒
+func SetRetryAfter(c *gin.Context, d time.Duration) {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"blog/apierror"
+	"blog/models"
+	"blog/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRole 从上下文获取当前用户的角色，未知角色按 reader 处理
+func GetRole(c *gin.Context) models.Role {
+	role, _ := c.Get("role")
+	if r, ok := role.(models.Role); ok && r.Valid() {
+		return r
+	}
+	return models.RoleReader
+}
+
+// RequireRole 只允许指定角色访问，需放在 AuthMiddleware 之后
+func RequireRole(roles ...models.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := GetRole(c)
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+
+		requestLogger(c).Info("role denied", "role", role, "required", roles)
+		response.Error(c, apierror.Forbidden("Insufficient role"))
+	}
+}
+
+// RequirePermission 只允许拥有指定权限的角色访问，需放在 AuthMiddleware 之后
+func RequirePermission(perm models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := GetRole(c)
+		if !role.Can(perm) {
+			requestLogger(c).Info("permission denied", "role", role, "permission", perm)
+			response.Error(c, apierror.Forbidden("Insufficient permission"))
+			return
+		}
+		c.Next()
+	}
+}
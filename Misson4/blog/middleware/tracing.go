@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"blog/response"
+	"blog/tracing"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing 为每个请求创建 span 并放入请求的 context，之后的数据库查询作为它的子 span。
+// 请求头带有 traceparent 时接入上游的链路。需放在 RequestID 之后、Logger 之前
+func Tracing() gin.HandlerFunc {
+	tracer := tracing.Tracer()
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		// 按路由模板命名，避免路径参数使 span 名称过多；没有匹配的路由时只用方法名
+		name := c.Request.Method
+		route := c.FullPath()
+		if route != "" {
+			name += " " + route
+		}
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+				semconv.ClientAddress(c.ClientIP()),
+				semconv.UserAgentOriginal(c.Request.UserAgent()),
+				attribute.String("request.id", response.RequestID(c)),
+			),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if userID, err := GetUserID(c); err == nil {
+			span.SetAttributes(semconv.EnduserID(strconv.FormatUint(uint64(userID), 10)))
+		}
+		if status >= http.StatusInternalServerError {
+			if len(c.Errors) > 0 {
+				span.RecordError(c.Errors.Last().Err)
+			}
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
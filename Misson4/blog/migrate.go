@@ -0,0 +1,66 @@
+package main
+
+import (
+	"blog/database"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// runMigrate 处理 migrate 子命令
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("migrate: missing subcommand (up, down, status)")
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := database.MigrateUp(database.DB)
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Println("No pending migrations")
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("migrate down: steps must be a positive integer, got %q", args[1])
+			}
+			steps = n
+		}
+		done, err := database.MigrateDown(database.DB, steps)
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Println("No applied migrations to revert")
+		}
+		return nil
+
+	case "status":
+		statuses, err := database.Status(database.DB)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			status, appliedAt := "pending", "-"
+			if s.Applied {
+				status, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("migrate: unknown subcommand %q (want up, down or status)", args[0])
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Attachment 上传的图片或附件。上传时可以不关联文章（PostID 为空），之后再关联到上传者的文章。
+// URL 在上传时根据存储配置生成；图片额外带有缩略图和原图尺寸。
+type Attachment struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;index"`
+	PostID       *uint     `json:"post_id" gorm:"index"`
+	Filename     string    `json:"filename" gorm:"type:varchar(255);not null"`
+	ContentType  string    `json:"content_type" gorm:"type:varchar(100);not null"`
+	Size         int64     `json:"size" gorm:"not null"`
+	StorageKey   string    `json:"-" gorm:"type:varchar(255);uniqueIndex;not null"`
+	URL          string    `json:"url" gorm:"type:varchar(500);not null"`
+	ThumbnailKey string    `json:"-" gorm:"type:varchar(255)"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty" gorm:"type:varchar(500)"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -8,14 +8,17 @@ import (
 
 // Comment 评论模型
 type Comment struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Content   string         `json:"content" gorm:"type:text;not null"`
-	UserID    uint           `json:"user_id" gorm:"not null;index"`
-	User      User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	PostID    uint           `json:"post_id" gorm:"not null;index"`
-	Post      Post           `json:"post,omitempty" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	Content string `json:"content" gorm:"type:text;not null"`
+	// ContentHTML 由 Content 渲染并过滤后的 HTML，在保存时生成
+	ContentHTML string         `json:"content_html" gorm:"type:text"`
+	UserID      uint           `json:"user_id" gorm:"not null;index"`
+	User        User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	PostID      uint           `json:"post_id" gorm:"not null;index"`
+	Post        Post           `json:"post,omitempty" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	ParentID    *uint          `json:"parent_id" gorm:"index"` // 回复的评论 ID，顶级评论为空
+	EditedAt    *time.Time     `json:"edited_at"`              // 作者最后一次编辑内容的时间，未编辑过为空
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
-
@@ -6,16 +6,58 @@ import (
 	"gorm.io/gorm"
 )
 
+// PostStatus 文章状态
+type PostStatus string
+
+// 文章状态，只有 published 的文章对外可见
+const (
+	PostDraft     PostStatus = "draft"     // 草稿
+	PostScheduled PostStatus = "scheduled" // 定时发布，到 PublishAt 后由后台任务发布
+	PostPublished PostStatus = "published"
+	PostArchived  PostStatus = "archived" // 已归档，不再公开
+)
+
+// Valid 是否为已知状态
+func (s PostStatus) Valid() bool {
+	switch s {
+	case PostDraft, PostScheduled, PostPublished, PostArchived:
+		return true
+	}
+	return false
+}
+
 // Post 文章模型
 type Post struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Title     string         `json:"title" gorm:"type:varchar(200);not null"`
-	Content   string         `json:"content" gorm:"type:text;not null"`
-	UserID    uint           `json:"user_id" gorm:"not null;index"`
-	User      User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	Comments  []Comment      `json:"comments,omitempty" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID    uint   `json:"id" gorm:"primaryKey"`
+	Title string `json:"title" gorm:"type:varchar(200);not null"`
+	// Slug 由标题生成的 URL 短名称，标题修改时随之更新，旧值记录在 PostSlug 中
+	Slug    string `json:"slug" gorm:"type:varchar(100);uniqueIndex"`
+	Content string `json:"content" gorm:"type:text;not null"`
+	// ContentHTML 由 Content 渲染并过滤后的 HTML，Excerpt 为列表使用的纯文本摘要，均在保存时生成
+	ContentHTML string    `json:"content_html" gorm:"type:text"`
+	Excerpt     string    `json:"excerpt" gorm:"type:varchar(300)"`
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	User        User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Comments    []Comment `json:"comments,omitempty" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	// Attachments 关联到文章的图片和附件，按上传顺序排列
+	Attachments []Attachment `json:"attachments,omitempty" gorm:"foreignKey:PostID;constraint:OnDelete:SET NULL"`
+	// CategoryID 所属分类，未分类时为空
+	CategoryID *uint      `json:"category_id" gorm:"index"`
+	Category   *Category  `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	Tags       []Tag      `json:"tags" gorm:"many2many:post_tags"`
+	Status     PostStatus `json:"status" gorm:"type:varchar(20);not null;default:published;index"`
+	// PublishAt 定时文章为计划发布时间，已发布文章为实际发布时间，草稿为空
+	PublishAt *time.Time `json:"publish_at" gorm:"index"`
+	// CommentCount 未删除的评论数，在发表和删除评论时同步更新
+	CommentCount int64 `json:"comment_count" gorm:"not null;default:0;index"`
+	// LikeCount 点赞数，在点赞和取消点赞时同步更新
+	LikeCount int64 `json:"like_count" gorm:"not null;default:0"`
+	// ViewCount 浏览量，先在内存中累计再定期写入，因此会略有延迟
+	ViewCount int64 `json:"view_count" gorm:"not null;default:0"`
+	// Liked、Bookmarked 当前登录用户是否已点赞、收藏，只在登录用户查看时填充，不保存到数据库
+	Liked      *bool          `json:"liked,omitempty" gorm:"-"`
+	Bookmarked *bool          `json:"bookmarked,omitempty" gorm:"-"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
 }
-
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Like 用户对文章的点赞，每个用户对同一篇文章最多点赞一次
+type Like struct {
+	UserID    uint      `json:"user_id" gorm:"primaryKey"`
+	PostID    uint      `json:"post_id" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Bookmark 用户收藏的文章
+type Bookmark struct {
+	UserID    uint      `json:"user_id" gorm:"primaryKey"`
+	PostID    uint      `json:"post_id" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// PostRevision 文章的一个版本。创建文章和每次修改标题或内容时记录修改后的完整内容，
+// Version 在同一篇文章内从 1 开始递增。
+type PostRevision struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	PostID   uint   `json:"post_id" gorm:"not null;uniqueIndex:idx_post_revisions_post_version"`
+	Version  int    `json:"version" gorm:"not null;uniqueIndex:idx_post_revisions_post_version"`
+	Title    string `json:"title" gorm:"type:varchar(200);not null"`
+	Content  string `json:"content,omitempty" gorm:"type:text;not null"`
+	EditorID uint   `json:"editor_id" gorm:"not null"`
+	Editor   *User  `json:"editor,omitempty" gorm:"foreignKey:EditorID"`
+	// RestoredFrom 由恢复历史版本产生时，记录被恢复的版本号
+	RestoredFrom *int      `json:"restored_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,49 @@
+package models
+
+// Role 用户角色
+type Role string
+
+// 角色，权限从低到高
+const (
+	RoleReader Role = "reader" // 只能评论
+	RoleAuthor Role = "author" // 可以发表文章，新注册用户的默认角色
+	RoleEditor Role = "editor" // 可以管理所有文章、评论和分类
+	RoleAdmin  Role = "admin"  // 可以管理用户
+)
+
+// Permission 权限
+type Permission string
+
+// 权限
+const (
+	PermCommentCreate   Permission = "comment:create"
+	PermPostCreate      Permission = "post:create"
+	PermPostModerate    Permission = "post:moderate"    // 修改、删除任意文章
+	PermCommentModerate Permission = "comment:moderate" // 删除任意评论
+	PermUserManage      Permission = "user:manage"      // 查看用户、修改角色
+	PermCategoryManage  Permission = "category:manage"  // 创建分类
+)
+
+// rolePermissions 每个角色拥有的权限
+var rolePermissions = map[Role][]Permission{
+	RoleReader: {PermCommentCreate},
+	RoleAuthor: {PermCommentCreate, PermPostCreate},
+	RoleEditor: {PermCommentCreate, PermPostCreate, PermPostModerate, PermCommentModerate, PermCategoryManage},
+	RoleAdmin:  {PermCommentCreate, PermPostCreate, PermPostModerate, PermCommentModerate, PermCategoryManage, PermUserManage},
+}
+
+// Valid 是否为已知角色
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can 角色是否拥有权限
+func (r Role) Can(p Permission) bool {
+	for _, perm := range rolePermissions[r] {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// PostSlug 文章用过的所有 slug（包括当前的），用于按旧 slug 访问时重定向到当前地址。
+// Slug 全局唯一，文章改名后旧 slug 不会被其他文章占用。
+type PostSlug struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PostID    uint      `json:"post_id" gorm:"not null;index"`
+	Slug      string    `json:"slug" gorm:"type:varchar(100);uniqueIndex;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
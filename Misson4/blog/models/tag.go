@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Tag 标签，名称统一保存为小写
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"type:varchar(50);uniqueIndex;not null"`
+	Posts     []Post    `json:"-" gorm:"many2many:post_tags"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Category 分类，每篇文章最多属于一个分类
+type Category struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"type:varchar(50);uniqueIndex;not null"`
+	Description string    `json:"description" gorm:"type:varchar(255)"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
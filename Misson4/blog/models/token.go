@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RefreshToken 刷新令牌，只保存令牌的 SHA-256 摘要。
+// 每次刷新都会作废旧令牌并在同一 FamilyID 下签发新令牌，
+// 已作废的令牌再次被使用说明令牌泄露，整个 family 都会被作废。
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID  string     `json:"family_id" gorm:"type:varchar(64);not null;index"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;index"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// RevokedToken 被注销的访问令牌（按 jti 记录），过期后可以清理
+type RevokedToken struct {
+	JTI       string    `gorm:"type:varchar(64);primaryKey"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
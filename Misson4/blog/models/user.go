@@ -8,14 +8,21 @@ import (
 
 // User 用户模型
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Username  string         `json:"username" gorm:"type:varchar(50);uniqueIndex;not null"`
-	Password  string         `json:"-" gorm:"type:varchar(255);not null"` // 密码不返回给客户端
-	Email     string         `json:"email" gorm:"type:varchar(100);uniqueIndex;not null"`
-	Posts     []Post         `json:"posts,omitempty" gorm:"foreignKey:UserID"`
-	Comments  []Comment      `json:"comments,omitempty" gorm:"foreignKey:UserID"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Username string `json:"username" gorm:"type:varchar(50);uniqueIndex;not null"`
+	Password string `json:"-" gorm:"type:varchar(255);not null"`             // 密码不返回给客户端
+	Email    string `json:"-" gorm:"type:varchar(100);uniqueIndex;not null"` // 邮箱只返回给本人和管理员，见 handlers.accountOf
+	Role     Role   `json:"role" gorm:"type:varchar(20);not null;default:author"`
+	// EmailVerifiedAt 邮箱验证通过的时间，未验证为空
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// DisplayName、Bio 和 AvatarURL 为用户自己维护的公开资料，都可以为空
+	DisplayName string         `json:"display_name" gorm:"type:varchar(50)"`
+	Bio         string         `json:"bio" gorm:"type:varchar(500)"`
+	AvatarURL   string         `json:"avatar_url" gorm:"type:varchar(255)"`
+	PostCount   int64          `json:"post_count" gorm:"not null;default:0"` // 未删除的文章数（包括草稿），创建和删除文章时同步更新
+	Posts       []Post         `json:"posts,omitempty" gorm:"foreignKey:UserID"`
+	Comments    []Comment      `json:"comments,omitempty" gorm:"foreignKey:UserID"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
-
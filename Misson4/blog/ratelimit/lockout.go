@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// LockoutPolicy 登录失败锁定策略：连续失败 MaxFailures 次后锁定 BaseDelay，
+// 之后每多失败一次锁定时间翻倍，最长 MaxDelay。失败计数在 Window 内没有新的失败时清零
+type LockoutPolicy struct {
+	MaxFailures int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Window      time.Duration
+}
+
+// Lockout 按账号记录登录失败次数并逐步延长锁定时间。MaxFailures 为 0 时不锁定
+type Lockout struct {
+	store  Store
+	policy LockoutPolicy
+}
+
+// NewLockout 创建 Lockout
+func NewLockout(store Store, policy LockoutPolicy) *Lockout {
+	return &Lockout{store: store, policy: policy}
+}
+
+// Locked 返回账号剩余的锁定时间，未锁定时为 0
+func (l *Lockout) Locked(ctx context.Context, account string) (time.Duration, error) {
+	if l.policy.MaxFailures <= 0 {
+		return 0, nil
+	}
+	return l.store.Blocked(ctx, lockKey(account))
+}
+
+// Fail 记录一次登录失败，达到锁定条件时锁定账号并返回锁定时间，否则返回 0
+func (l *Lockout) Fail(ctx context.Context, account string) (time.Duration, error) {
+	if l.policy.MaxFailures <= 0 {
+		return 0, nil
+	}
+	n, err := l.store.Incr(ctx, failKey(account), l.policy.Window)
+	if err != nil {
+		return 0, err
+	}
+	if n < int64(l.policy.MaxFailures) {
+		return 0, nil
+	}
+
+	d := l.delay(n - int64(l.policy.MaxFailures))
+	if err := l.store.Block(ctx, lockKey(account), d); err != nil {
+		return 0, err
+	}
+	return d, nil
+}
+
+// Succeed 登录成功后清除失败计数
+func (l *Lockout) Succeed(ctx context.Context, account string) error {
+	if l.policy.MaxFailures <= 0 {
+		return nil
+	}
+	return l.store.Reset(ctx, failKey(account), lockKey(account))
+}
+
+// delay 超过失败次数上限 extra 次时的锁定时间
+func (l *Lockout) delay(extra int64) time.Duration {
+	d := l.policy.BaseDelay
+	for i := int64(0); i < extra && d < l.policy.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > l.policy.MaxDelay {
+		d = l.policy.MaxDelay
+	}
+	return d
+}
+
+func failKey(account string) string { return "login-fail:" + account }
+func lockKey(account string) string { return "login-lock:" + account }
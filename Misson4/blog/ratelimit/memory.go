@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval 内存存储清理过期状态的最小间隔
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+type counter struct {
+	n       int64
+	expires time.Time
+}
+
+// Memory 进程内的 Store，重启后状态丢失，多实例部署时各实例单独计数
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	counters  map[string]counter
+	blocks    map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemory 创建 Memory
+func NewMemory() *Memory {
+	return &Memory{
+		buckets:  make(map[string]*bucket),
+		counters: make(map[string]counter),
+		blocks:   make(map[string]time.Time),
+	}
+}
+
+func (m *Memory) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		m.buckets[key] = b
+	}
+	var result Result
+	b.tokens, result = refill(b.tokens, now.Sub(b.updated), limit)
+	b.updated = now
+	b.limit = limit
+	return result, nil
+}
+
+func (m *Memory) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+
+	c := m.counters[key]
+	if !now.Before(c.expires) {
+		c.n = 0
+	}
+	c.n++
+	c.expires = now.Add(ttl)
+	m.counters[key] = c
+	return c.n, nil
+}
+
+func (m *Memory) Block(ctx context.Context, key string, d time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.blocks[key] = time.Now().Add(d)
+	return nil
+}
+
+func (m *Memory) Blocked(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	until, ok := m.blocks[key]
+	if !ok {
+		return 0, nil
+	}
+	if left := until.Sub(time.Now()); left > 0 {
+		return left, nil
+	}
+	delete(m.blocks, key)
+	return 0, nil
+}
+
+func (m *Memory) Reset(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.buckets, key)
+		delete(m.counters, key)
+		delete(m.blocks, key)
+	}
+	return nil
+}
+
+// sweep 每隔 sweepInterval 删除已经补满的令牌桶和过期的计数、封禁，调用方需持有锁
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+
+	for key, b := range m.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*b.limit.Rate >= float64(b.limit.Burst) {
+			delete(m.buckets, key)
+		}
+	}
+	for key, c := range m.counters {
+		if !now.Before(c.expires) {
+			delete(m.counters, key)
+		}
+	}
+	for key, until := range m.blocks {
+		if !now.Before(until) {
+			delete(m.blocks, key)
+		}
+	}
+}
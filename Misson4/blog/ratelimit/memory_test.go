@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRefill(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 5}
+	tests := []struct {
+		name    string
+		tokens  float64
+		elapsed time.Duration
+		left    float64
+		want    Result
+	}{
+		{"full bucket", 5, 0, 4, Result{Allowed: true, Remaining: 4}},
+		{"last token", 1, 0, 0, Result{Allowed: true, Remaining: 0}},
+		{"empty bucket", 0, 0, 0, Result{Allowed: false, RetryAfter: 500 * time.Millisecond}},
+		{"half a token", 0.5, 0, 0.5, Result{Allowed: false, RetryAfter: 250 * time.Millisecond}},
+		{"refilled after wait", 0, time.Second, 1, Result{Allowed: true, Remaining: 1}},
+		{"refill capped at burst", 0, time.Hour, 4, Result{Allowed: true, Remaining: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, got := refill(tt.tokens, tt.elapsed, limit)
+			if left != tt.left || got != tt.want {
+				t.Errorf("refill() = %v, %+v; want %v, %+v", left, got, tt.left, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryTake(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	limit := Limit{Rate: 0.001, Burst: 3}
+
+	for i, want := range []bool{true, true, true, false, false} {
+		res, err := m.Take(ctx, "ip:1", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Allowed != want {
+			t.Fatalf("request %d: Allowed = %v, want %v", i+1, res.Allowed, want)
+		}
+		if !res.Allowed && res.RetryAfter <= 0 {
+			t.Fatalf("request %d: RetryAfter = %v, want positive", i+1, res.RetryAfter)
+		}
+	}
+
+	// 不同的 key 使用各自的令牌桶
+	if res, _ := m.Take(ctx, "ip:2", limit); !res.Allowed {
+		t.Error("another key is limited")
+	}
+	// Reset 后桶重新视为满的
+	if err := m.Reset(ctx, "ip:1"); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := m.Take(ctx, "ip:1", limit); !res.Allowed || res.Remaining != 2 {
+		t.Errorf("after Reset: %+v, want allowed with 2 remaining", res)
+	}
+}
+
+func TestMemoryIncr(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	for want := int64(1); want <= 3; want++ {
+		n, err := m.Incr(ctx, "fail", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Fatalf("Incr() = %d, want %d", n, want)
+		}
+	}
+
+	// 过期后重新从 1 开始计数
+	if _, err := m.Incr(ctx, "short", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if n, _ := m.Incr(ctx, "short", time.Millisecond); n != 1 {
+		t.Errorf("Incr() after ttl = %d, want 1", n)
+	}
+}
+
+func TestMemoryBlock(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	if d, _ := m.Blocked(ctx, "lock"); d != 0 {
+		t.Fatalf("Blocked() = %v before Block", d)
+	}
+	if err := m.Block(ctx, "lock", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := m.Blocked(ctx, "lock"); d <= 0 || d > time.Minute {
+		t.Fatalf("Blocked() = %v, want (0, 1m]", d)
+	}
+	if err := m.Reset(ctx, "lock"); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := m.Blocked(ctx, "lock"); d != 0 {
+		t.Fatalf("Blocked() = %v after Reset", d)
+	}
+
+	if err := m.Block(ctx, "short", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if d, _ := m.Blocked(ctx, "short"); d != 0 {
+		t.Errorf("Blocked() = %v after the block expired", d)
+	}
+}
+
+func TestLockout(t *testing.T) {
+	ctx := context.Background()
+	policy := LockoutPolicy{MaxFailures: 3, BaseDelay: time.Minute, MaxDelay: 5 * time.Minute, Window: time.Hour}
+	l := NewLockout(NewMemory(), policy)
+
+	// 第 3 次失败开始锁定，之后每次翻倍，最长 MaxDelay
+	want := []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute}
+	for i, w := range want {
+		d, err := l.Fail(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d != w {
+			t.Fatalf("failure %d: Fail() = %v, want %v", i+1, d, w)
+		}
+	}
+	if d, _ := l.Locked(ctx, "alice"); d <= 0 {
+		t.Fatal("account is not locked")
+	}
+	if d, _ := l.Locked(ctx, "bob"); d != 0 {
+		t.Fatal("another account is locked")
+	}
+
+	if err := l.Succeed(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := l.Locked(ctx, "alice"); d != 0 {
+		t.Fatalf("Locked() = %v after Succeed", d)
+	}
+	if d, _ := l.Fail(ctx, "alice"); d != 0 {
+		t.Errorf("failure count was not reset: Fail() = %v", d)
+	}
+
+	disabled := NewLockout(NewMemory(), LockoutPolicy{})
+	for i := 0; i < 10; i++ {
+		if d, _ := disabled.Fail(ctx, "alice"); d != 0 {
+			t.Fatalf("lockout with MaxFailures 0 locked the account for %v", d)
+		}
+	}
+}
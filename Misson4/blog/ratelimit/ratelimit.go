@@ -0,0 +1,86 @@
+// Package ratelimit 提供令牌桶限流和登录失败锁定。
+// 状态保存在 Store 中：单实例部署使用内存实现，多实例部署使用 Redis 兼容的实现共享状态。
+package ratelimit
+
+import (
+	"blog/config"
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix Redis 中限流状态键的前缀
+const redisKeyPrefix = "blog:ratelimit:"
+
+// Limit 令牌桶参数：桶容量为 Burst，每秒补充 Rate 个令牌，每个请求消耗一个令牌
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result 一次取令牌的结果
+type Result struct {
+	Allowed bool
+	// Remaining 取令牌后桶中剩余的完整令牌数
+	Remaining int
+	// RetryAfter 被拒绝时距离下一个令牌可用的时间
+	RetryAfter time.Duration
+}
+
+// Store 限流和锁定状态的存储，实现需要保证每个方法对同一个 key 是原子的
+type Store interface {
+	// Take 从 key 对应的令牌桶中取一个令牌，桶不存在时视为满的
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// Incr 把 key 的计数加一并返回新值，计数在最后一次增加 ttl 后过期
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Block 封禁 key，持续 d
+	Block(ctx context.Context, key string, d time.Duration) error
+	// Blocked 返回 key 剩余的封禁时间，未封禁时为 0
+	Blocked(ctx context.Context, key string) (time.Duration, error)
+	// Reset 删除 keys 的计数和封禁
+	Reset(ctx context.Context, keys ...string) error
+}
+
+// New 根据配置创建 Store，使用 Redis 时先检查连接是否可用
+func New(ctx context.Context, cfg config.RateLimitConfig) (Store, error) {
+	switch cfg.Store {
+	case config.RateLimitMemory:
+		return NewMemory(), nil
+	case config.RateLimitRedis:
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		if err := client.Ping(ctx).Err(); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("ratelimit: connect to redis %s: %w", cfg.Redis.Addr, err)
+		}
+		return NewRedis(client, redisKeyPrefix), nil
+	default:
+		return nil, fmt.Errorf("ratelimit: unknown store %q", cfg.Store)
+	}
+}
+
+// refill 按经过的时间补充令牌并尝试取一个，返回取之后的令牌数和结果。
+// 内存实现直接使用；Redis 实现在 Lua 脚本中执行相同的计算。
+func refill(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	burst := float64(limit.Burst)
+	if elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed.Seconds()*limit.Rate)
+	}
+	return take(tokens, limit)
+}
+
+// take 根据当前令牌数决定是否放行
+func take(tokens float64, limit Limit) (float64, Result) {
+	if tokens >= 1 {
+		tokens--
+		return tokens, Result{Allowed: true, Remaining: int(tokens)}
+	}
+	wait := time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	return tokens, Result{Allowed: false, RetryAfter: wait}
+}
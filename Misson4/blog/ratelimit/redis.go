@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript 原子地执行令牌桶计算，状态保存在哈希的 t（令牌数）和 u（更新时间，毫秒）字段中。
+// 令牌数以字符串返回，避免 Lua 数字转换为 Redis 整数时丢失小数部分。
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 't', 'u')
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+if tokens == nil or updated == nil then
+  tokens = burst
+  updated = now
+end
+if now > updated then
+  tokens = math.min(burst, tokens + (now - updated) / 1000 * rate)
+end
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 't', tostring(tokens), 'u', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// Redis 使用 Redis 兼容服务（Redis、Valkey、KeyDB 等）的 Store，多个服务实例共享限流状态。
+// 令牌桶的时间取自服务实例的时钟，各实例的时钟需要大致同步。
+type Redis struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedis 创建 Redis，所有键都加上 prefix 前缀
+func NewRedis(client redis.UniversalClient, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix}
+}
+
+func (r *Redis) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now().UnixMilli()
+	reply, err := takeScript.Run(ctx, r.client, []string{r.prefix + key}, limit.Rate, limit.Burst, now).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(reply) != 2 {
+		return Result{}, errors.New("ratelimit: unexpected script reply")
+	}
+	allowed, _ := reply[0].(int64)
+	s, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return Result{}, errors.New("ratelimit: unexpected script reply")
+	}
+
+	if allowed == 1 {
+		return Result{Allowed: true, Remaining: int(math.Floor(tokens))}, nil
+	}
+	_, result := take(tokens, limit)
+	return result, nil
+}
+
+func (r *Redis) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, r.prefix+key)
+		pipe.PExpire(ctx, r.prefix+key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (r *Redis) Block(ctx context.Context, key string, d time.Duration) error {
+	return r.client.Set(ctx, r.prefix+key, 1, d).Err()
+}
+
+func (r *Redis) Blocked(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.client.PTTL(ctx, r.prefix+key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// 键不存在时为 -2，没有过期时间时为 -1（不会由 Block 设置）
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (r *Redis) Reset(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = r.prefix + key
+	}
+	return r.client.Del(ctx, prefixed...).Err()
+}
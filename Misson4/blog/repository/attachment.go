@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"blog/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// AttachmentRepository 附件数据访问接口
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *models.Attachment) error
+	FindByID(ctx context.Context, id uint) (*models.Attachment, error)
+	// FindByIDs 按 ID 批量查找附件，不存在的 ID 被忽略
+	FindByIDs(ctx context.Context, ids []uint) ([]models.Attachment, error)
+	// ListByPost 返回文章的附件，按上传顺序排列
+	ListByPost(ctx context.Context, postID uint) ([]models.Attachment, error)
+	// LinkToPost 把附件关联到文章
+	LinkToPost(ctx context.Context, ids []uint, postID uint) error
+	Delete(ctx context.Context, attachment *models.Attachment) error
+}
+
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+// NewAttachmentRepository 创建基于 gorm 的 AttachmentRepository
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+func (r *attachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	return r.db.WithContext(ctx).Create(attachment).Error
+}
+
+func (r *attachmentRepository) FindByID(ctx context.Context, id uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	if err := r.db.WithContext(ctx).First(&attachment, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &attachment, nil
+}
+
+func (r *attachmentRepository) FindByIDs(ctx context.Context, ids []uint) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *attachmentRepository) ListByPost(ctx context.Context, postID uint) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.WithContext(ctx).Where("post_id = ?", postID).Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *attachmentRepository) LinkToPost(ctx context.Context, ids []uint, postID uint) error {
+	return r.db.WithContext(ctx).Model(&models.Attachment{}).
+		Where("id IN ?", ids).
+		Update("post_id", postID).Error
+}
+
+func (r *attachmentRepository) Delete(ctx context.Context, attachment *models.Attachment) error {
+	return r.db.WithContext(ctx).Delete(attachment).Error
+}
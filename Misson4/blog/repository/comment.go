@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"blog/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// liveAncestorIDs 从文章中未删除的评论沿 parent_id 逐层向上查出它们的全部祖先评论 ID，
+// 这样已删除评论下即使隔了多层已删除的回复，只要还有未删除的后代就会被保留。
+// 不使用递归 CTE，MySQL 5.7 也能执行。
+func liveAncestorIDs(db *gorm.DB, postID uint) ([]uint, error) {
+	var level []uint
+	err := db.Model(&models.Comment{}).Distinct().
+		Where("post_id = ? AND parent_id IS NOT NULL", postID).
+		Pluck("parent_id", &level).Error
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uint]bool)
+	var ids []uint
+	for len(level) > 0 {
+		var fresh []uint
+		for _, id := range level {
+			if !seen[id] {
+				seen[id] = true
+				fresh = append(fresh, id)
+			}
+		}
+		if len(fresh) == 0 {
+			break
+		}
+		ids = append(ids, fresh...)
+
+		level = nil
+		err := db.Unscoped().Model(&models.Comment{}).Distinct().
+			Where("id IN ? AND parent_id IS NOT NULL", fresh).
+			Pluck("parent_id", &level).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// visibleComments 文章中未删除的评论，以及已删除但仍有未删除后代的评论
+func visibleComments(db *gorm.DB, postID uint) (*gorm.DB, error) {
+	ancestors, err := liveAncestorIDs(db, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	db = db.Unscoped().Where("comments.post_id = ?", postID)
+	if len(ancestors) == 0 {
+		return db.Where("comments.deleted_at IS NULL"), nil
+	}
+	return db.Where("comments.deleted_at IS NULL OR comments.id IN ?", ancestors), nil
+}
+
+// CommentCursor 顶级评论游标分页的位置：上一页最后一条评论的创建时间和 ID
+type CommentCursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// CommentRepository 评论数据访问接口
+type CommentRepository interface {
+	// Create 创建评论并增加文章的评论数
+	Create(ctx context.Context, comment *models.Comment) error
+	// FindByID 查找评论并加载作者
+	FindByID(ctx context.Context, id uint) (*models.Comment, error)
+	// Update 保存评论本身的字段，不写回预加载的作者等关联
+	Update(ctx context.Context, comment *models.Comment) error
+	// Delete 软删除评论并减少文章的评论数
+	Delete(ctx context.Context, comment *models.Comment) error
+	// ListTopLevel 按创建时间倒序分页返回文章的顶级评论（含作者），after 为空时从头开始。
+	// 已删除但仍有未删除后代的评论也会返回，以便保留讨论结构，调用方需根据 DeletedAt 隐藏内容。
+	ListTopLevel(ctx context.Context, postID uint, after *CommentCursor, limit int) ([]models.Comment, error)
+	// CountTopLevel 返回文章的顶级评论数
+	CountTopLevel(ctx context.Context, postID uint) (int64, error)
+	// ListReplies 按创建时间正序返回文章中 parentIDs 的直接回复（含作者），已删除评论的处理同 ListTopLevel
+	ListReplies(ctx context.Context, postID uint, parentIDs []uint) ([]models.Comment, error)
+}
+
+type commentRepository struct {
+	db *gorm.DB
+}
+
+// NewCommentRepository 创建基于 gorm 的 CommentRepository
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+func (r *commentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		return addCommentCount(tx, comment.PostID, 1)
+	})
+}
+
+func (r *commentRepository) FindByID(ctx context.Context, id uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.WithContext(ctx).Preload("User", withDeletedUsers).First(&comment, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &comment, nil
+}
+
+func (r *commentRepository) Update(ctx context.Context, comment *models.Comment) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(comment).Error
+}
+
+func (r *commentRepository) Delete(ctx context.Context, comment *models.Comment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(comment)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return addCommentCount(tx, comment.PostID, -1)
+	})
+}
+
+// addCommentCount 调整文章的评论数，不修改 updated_at
+func addCommentCount(tx *gorm.DB, postID uint, delta int) error {
+	return tx.Model(&models.Post{}).Where("id = ?", postID).
+		UpdateColumn("comment_count", gorm.Expr("comment_count + ?", delta)).Error
+}
+
+func (r *commentRepository) ListTopLevel(ctx context.Context, postID uint, after *CommentCursor, limit int) ([]models.Comment, error) {
+	db, err := visibleComments(r.db.WithContext(ctx), postID)
+	if err != nil {
+		return nil, err
+	}
+	db = db.Preload("User", withDeletedUsers).Where("comments.parent_id IS NULL")
+	if after != nil {
+		db = db.Where("comments.created_at < ? OR (comments.created_at = ? AND comments.id < ?)", after.CreatedAt, after.CreatedAt, after.ID)
+	}
+
+	var comments []models.Comment
+	if err := db.Order("comments.created_at desc").Order("comments.id desc").Limit(limit).Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *commentRepository) CountTopLevel(ctx context.Context, postID uint) (int64, error) {
+	db, err := visibleComments(r.db.WithContext(ctx), postID)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = db.Model(&models.Comment{}).Where("comments.parent_id IS NULL").Count(&count).Error
+	return count, err
+}
+
+func (r *commentRepository) ListReplies(ctx context.Context, postID uint, parentIDs []uint) ([]models.Comment, error) {
+	if len(parentIDs) == 0 {
+		return nil, nil
+	}
+	db, err := visibleComments(r.db.WithContext(ctx), postID)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []models.Comment
+	err = db.Preload("User", withDeletedUsers).
+		Where("comments.parent_id IN ?", parentIDs).
+		Order("comments.created_at asc").Order("comments.id asc").
+		Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
@@ -0,0 +1,164 @@
+package repository_test
+
+import (
+	"blog/config"
+	"blog/database"
+	"blog/models"
+	"blog/repository"
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// openDB 打开迁移到最新版本的内存 SQLite 数据库
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(config.DatabaseConfig{Driver: config.DriverSQLite, DSN: ":memory:"}, config.LogLevelError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// commentIDs 返回评论的 ID 列表
+func commentIDs(comments []models.Comment) []uint {
+	ids := make([]uint, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+func TestCommentVisibilityKeepsDeletedAncestorsOfLiveReplies(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	repo := repository.NewCommentRepository(db)
+
+	user := models.User{Username: "alice", Password: "x", Email: "alice@example.com"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	post := models.Post{Title: "p", Slug: "p", Content: "p", UserID: user.ID, Status: models.PostPublished}
+	if err := db.Create(&post).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// a（已删除）→ b（已删除）→ c；c 下的 d 已删除；e（已删除）→ f（已删除）
+	create := func(parent *models.Comment) *models.Comment {
+		t.Helper()
+		c := &models.Comment{Content: "x", UserID: user.ID, PostID: post.ID}
+		if parent != nil {
+			c.ParentID = &parent.ID
+		}
+		if err := db.Create(c).Error; err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	a := create(nil)
+	b := create(a)
+	c := create(b)
+	d := create(c)
+	e := create(nil)
+	f := create(e)
+	for _, del := range []*models.Comment{a, b, d, e, f} {
+		if err := db.Delete(del).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		parents []uint
+		want    []uint
+	}{
+		{"top level", nil, []uint{a.ID}},
+		{"deleted reply with live descendant", []uint{a.ID}, []uint{b.ID}},
+		{"live reply", []uint{b.ID}, []uint{c.ID}},
+		{"deleted leaf", []uint{c.ID}, nil},
+		{"deleted subtree", []uint{e.ID}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []models.Comment
+			var err error
+			if tt.parents == nil {
+				got, err = repo.ListTopLevel(ctx, post.ID, nil, 10)
+			} else {
+				got, err = repo.ListReplies(ctx, post.ID, tt.parents)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := commentIDs(got)
+			if len(ids) != len(tt.want) {
+				t.Fatalf("got %v, want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", ids, tt.want)
+				}
+			}
+		})
+	}
+
+	count, err := repo.CountTopLevel(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("CountTopLevel() = %d, want 1", count)
+	}
+}
+
+func TestCommentUpdateOmitsAssociations(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	repo := repository.NewCommentRepository(db)
+
+	user := models.User{Username: "alice", Password: "x", Email: "alice@example.com"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	post := models.Post{Title: "p", Slug: "p", Content: "p", UserID: user.ID, Status: models.PostPublished}
+	if err := db.Create(&post).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Create(ctx, &models.Comment{Content: "old", UserID: user.ID, PostID: post.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	comment, err := repo.FindByID(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 记录保存评论时写入的表，预加载的作者不能被一并写回
+	var tables []string
+	record := func(tx *gorm.DB) { tables = append(tables, tx.Statement.Table) }
+	if err := db.Callback().Create().Before("gorm:create").Register("test:record_create", record); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("test:record_update", record); err != nil {
+		t.Fatal(err)
+	}
+
+	comment.Content = "new"
+	if err := repo.Update(ctx, comment); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range tables {
+		if table != "comments" {
+			t.Errorf("Update() wrote table %q, want only comments", table)
+		}
+	}
+}
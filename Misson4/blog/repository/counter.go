@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// 根据源数据重新计算冗余计数的子查询
+const (
+	postCountExpr    = "(SELECT COUNT(*) FROM posts WHERE posts.user_id = users.id AND posts.deleted_at IS NULL)"
+	commentCountExpr = "(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL)"
+	likeCountExpr    = "(SELECT COUNT(*) FROM likes WHERE likes.post_id = posts.id)"
+)
+
+// CounterFixes 校正计数时每种计数被修正的行数
+type CounterFixes struct {
+	UserPostCounts    int64
+	PostCommentCounts int64
+	PostLikeCounts    int64
+}
+
+// CounterRepository 冗余计数的校正
+type CounterRepository interface {
+	// Reconcile 根据文章、评论和点赞记录重新计算 users.post_count、posts.comment_count
+	// 和 posts.like_count，只更新与源数据不一致的行
+	Reconcile(ctx context.Context) (*CounterFixes, error)
+}
+
+type counterRepository struct {
+	db *gorm.DB
+}
+
+// NewCounterRepository 创建基于 gorm 的 CounterRepository
+func NewCounterRepository(db *gorm.DB) CounterRepository {
+	return &counterRepository{db: db}
+}
+
+func (r *counterRepository) Reconcile(ctx context.Context) (*CounterFixes, error) {
+	fixes := &CounterFixes{}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		steps := []struct {
+			table, column, expr string
+			fixed               *int64
+		}{
+			{"users", "post_count", postCountExpr, &fixes.UserPostCounts},
+			{"posts", "comment_count", commentCountExpr, &fixes.PostCommentCounts},
+			{"posts", "like_count", likeCountExpr, &fixes.PostLikeCounts},
+		}
+		for _, step := range steps {
+			result := tx.Exec("UPDATE " + step.table + " SET " + step.column + " = " + step.expr +
+				" WHERE " + step.column + " <> " + step.expr)
+			if result.Error != nil {
+				return result.Error
+			}
+			*step.fixed = result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fixes, nil
+}
@@ -0,0 +1,379 @@
+package repository
+
+import (
+	"blog/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostSort 文章列表排序字段
+type PostSort string
+
+// 支持的排序字段
+const (
+	SortCreatedAt    PostSort = "created_at"
+	SortUpdatedAt    PostSort = "updated_at"
+	SortCommentCount PostSort = "comment_count"
+)
+
+// PostCursor 游标分页的位置：上一页最后一条记录的排序值和 ID。
+// 按时间排序时使用 Time，按评论数排序时使用 Count。
+type PostCursor struct {
+	Time  time.Time
+	Count int64
+	ID    uint
+}
+
+// PostQuery 文章列表查询条件
+type PostQuery struct {
+	AuthorID     uint
+	Status       models.PostStatus // 为空表示不过滤
+	Tag          string            // 标签名，为空表示不过滤
+	CategoryID   uint
+	BookmarkedBy uint       // 只返回该用户收藏的文章
+	CreatedFrom  *time.Time // 包含
+	CreatedTo    *time.Time // 不包含
+	Sort         PostSort
+	Desc         bool
+	// After 不为空时使用游标分页并忽略 Offset
+	After  *PostCursor
+	Offset int
+	Limit  int
+}
+
+// saveOmits 保存文章时跳过的字段：关联，以及由评论、点赞、浏览计数单独维护的列（以免写回读取时的旧值）
+var saveOmits = []string{clause.Associations, "comment_count", "like_count", "view_count"}
+
+// PostRepository 文章数据访问接口
+type PostRepository interface {
+	// Create 创建文章，同时保存 post.Tags 中的标签关联、登记 slug、增加作者的文章数并记录第 1 版
+	Create(ctx context.Context, post *models.Post) error
+	// FindByID 查找文章并加载作者、分类、标签和附件
+	FindByID(ctx context.Context, id uint) (*models.Post, error)
+	// FindBySlug 按当前或历史 slug 查找文章，加载内容同 FindByID
+	FindBySlug(ctx context.Context, slug string) (*models.Post, error)
+	// SlugTaken slug 是否被 postID 以外的文章使用过（包括历史 slug）
+	SlugTaken(ctx context.Context, slug string, postID uint) (bool, error)
+	// List 按条件分页查询文章（加载作者、分类、标签和评论数），同时返回不分页时的总数
+	List(ctx context.Context, q PostQuery) ([]models.Post, int64, error)
+	// Update 保存文章自身的字段，不修改任何关联；slug 有变化时登记新的 slug
+	Update(ctx context.Context, post *models.Post) error
+	// UpdateWithRevision 在同一事务中保存文章并记录新版本。
+	// revision 只需填写 EditorID 和 RestoredFrom，其余字段由文章当前内容和下一个版本号填充。
+	UpdateWithRevision(ctx context.Context, post *models.Post, revision *models.PostRevision) error
+	// UpdateWithTags 在同一事务中保存文章并把标签替换为 tags；revision 不为 nil 时同时记录新版本，填写方式同 UpdateWithRevision
+	UpdateWithTags(ctx context.Context, post *models.Post, revision *models.PostRevision, tags []models.Tag) error
+	// ListRevisions 按版本号降序返回文章的全部版本（加载编辑者，不含内容）
+	ListRevisions(ctx context.Context, postID uint) ([]models.PostRevision, error)
+	// FindRevision 查找文章的某个版本
+	FindRevision(ctx context.Context, postID uint, version int) (*models.PostRevision, error)
+	// Delete 软删除文章并减少作者的文章数
+	Delete(ctx context.Context, post *models.Post) error
+	// AppendTags 为文章追加标签，已关联的标签会被忽略
+	AppendTags(ctx context.Context, post *models.Post, tags []models.Tag) error
+	// RemoveTag 解除文章与标签的关联
+	RemoveTag(ctx context.Context, post *models.Post, tag *models.Tag) error
+	// AddViews 为文章累加浏览量，counts 的键为文章 ID
+	AddViews(ctx context.Context, counts map[uint]int64) error
+	// PublishDue 把发布时间不晚于 now 的定时文章改为已发布，返回被发布的文章
+	PublishDue(ctx context.Context, now time.Time) ([]models.Post, error)
+}
+
+type postRepository struct {
+	db *gorm.DB
+}
+
+// NewPostRepository 创建基于 gorm 的 PostRepository
+func NewPostRepository(db *gorm.DB) PostRepository {
+	return &postRepository{db: db}
+}
+
+func (r *postRepository) Create(ctx context.Context, post *models.Post) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(post).Error; err != nil {
+			return err
+		}
+		if err := registerSlug(tx, post); err != nil {
+			return err
+		}
+		if err := addPostCount(tx, post.UserID, 1); err != nil {
+			return err
+		}
+		return tx.Create(&models.PostRevision{
+			PostID:   post.ID,
+			Version:  1,
+			Title:    post.Title,
+			Content:  post.Content,
+			EditorID: post.UserID,
+		}).Error
+	})
+}
+
+// preloadTaxonomy 加载文章的分类和按名称排序的标签
+func preloadTaxonomy(db *gorm.DB) *gorm.DB {
+	return db.Preload("Category").Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Order("tags.name")
+	})
+}
+
+// preloadAttachments 按上传顺序加载文章的附件
+func preloadAttachments(db *gorm.DB) *gorm.DB {
+	return db.Preload("Attachments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("attachments.id")
+	})
+}
+
+func (r *postRepository) FindByID(ctx context.Context, id uint) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.WithContext(ctx).Preload("User", withDeletedUsers).Scopes(preloadTaxonomy, preloadAttachments).First(&post, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &post, nil
+}
+
+func (r *postRepository) FindBySlug(ctx context.Context, slug string) (*models.Post, error) {
+	var ps models.PostSlug
+	if err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&ps).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return r.FindByID(ctx, ps.PostID)
+}
+
+func (r *postRepository) SlugTaken(ctx context.Context, slug string, postID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.PostSlug{}).
+		Where("slug = ? AND post_id <> ?", slug, postID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// registerSlug 把文章当前的 slug 登记到 post_slugs，已登记过时不做任何操作，
+// slug 已属于其他文章时返回 ErrSlugTaken
+func registerSlug(tx *gorm.DB, post *models.Post) error {
+	var owner models.PostSlug
+	if err := tx.Where("slug = ?", post.Slug).Limit(1).Find(&owner).Error; err != nil {
+		return err
+	}
+	if owner.ID != 0 {
+		if owner.PostID != post.ID {
+			return ErrSlugTaken
+		}
+		return nil
+	}
+	return tx.Create(&models.PostSlug{PostID: post.ID, Slug: post.Slug}).Error
+}
+
+func (r *postRepository) List(ctx context.Context, q PostQuery) ([]models.Post, int64, error) {
+	db := r.db.WithContext(ctx).Model(&models.Post{})
+	if q.AuthorID != 0 {
+		db = db.Where("posts.user_id = ?", q.AuthorID)
+	}
+	if q.Status != "" {
+		db = db.Where("posts.status = ?", q.Status)
+	}
+	if q.Tag != "" {
+		db = db.Where("posts.id IN (?)", r.db.Table("post_tags").
+			Select("post_tags.post_id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").
+			Where("tags.name = ?", q.Tag))
+	}
+	if q.CategoryID != 0 {
+		db = db.Where("posts.category_id = ?", q.CategoryID)
+	}
+	if q.BookmarkedBy != 0 {
+		db = db.Where("posts.id IN (?)", r.db.Model(&models.Bookmark{}).
+			Select("post_id").
+			Where("user_id = ?", q.BookmarkedBy))
+	}
+	if q.CreatedFrom != nil {
+		db = db.Where("posts.created_at >= ?", *q.CreatedFrom)
+	}
+	if q.CreatedTo != nil {
+		db = db.Where("posts.created_at < ?", *q.CreatedTo)
+	}
+
+	var total int64
+	if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column := "posts.created_at"
+	switch q.Sort {
+	case SortUpdatedAt:
+		column = "posts.updated_at"
+	case SortCommentCount:
+		column = "posts.comment_count"
+	}
+	cmp, dir := ">", "ASC"
+	if q.Desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	if q.After != nil {
+		var value interface{} = q.After.Time
+		if q.Sort == SortCommentCount {
+			value = q.After.Count
+		}
+		db = db.Where(
+			column+" "+cmp+" ? OR ("+column+" = ? AND posts.id "+cmp+" ?)",
+			value, value, q.After.ID,
+		)
+	} else if q.Offset > 0 {
+		db = db.Offset(q.Offset)
+	}
+
+	var posts []models.Post
+	err := db.Preload("User", withDeletedUsers).
+		Scopes(preloadTaxonomy).
+		Order(column + " " + dir).
+		Order("posts.id " + dir).
+		Limit(q.Limit).
+		Find(&posts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return posts, total, nil
+}
+
+func (r *postRepository) Update(ctx context.Context, post *models.Post) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return savePost(tx, post)
+	})
+}
+
+func (r *postRepository) UpdateWithRevision(ctx context.Context, post *models.Post, revision *models.PostRevision) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := savePost(tx, post); err != nil {
+			return err
+		}
+		return addRevision(tx, post, revision)
+	})
+}
+
+func (r *postRepository) UpdateWithTags(ctx context.Context, post *models.Post, revision *models.PostRevision, tags []models.Tag) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := savePost(tx, post); err != nil {
+			return err
+		}
+		if revision != nil {
+			if err := addRevision(tx, post, revision); err != nil {
+				return err
+			}
+		}
+		return tx.Model(post).Association("Tags").Replace(tags)
+	})
+}
+
+// savePost 保存文章自身的字段，slug 有变化时登记新的 slug
+func savePost(tx *gorm.DB, post *models.Post) error {
+	if err := tx.Omit(saveOmits...).Save(post).Error; err != nil {
+		return err
+	}
+	return registerSlug(tx, post)
+}
+
+// addRevision 以文章当前的标题和内容记录下一个版本
+func addRevision(tx *gorm.DB, post *models.Post, revision *models.PostRevision) error {
+	// 并发修改同一篇文章时，(post_id, version) 的唯一索引保证版本号不重复
+	var latest int
+	err := tx.Model(&models.PostRevision{}).
+		Where("post_id = ?", post.ID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error
+	if err != nil {
+		return err
+	}
+
+	revision.PostID = post.ID
+	revision.Version = latest + 1
+	revision.Title = post.Title
+	revision.Content = post.Content
+	return tx.Create(revision).Error
+}
+
+func (r *postRepository) ListRevisions(ctx context.Context, postID uint) ([]models.PostRevision, error) {
+	var revisions []models.PostRevision
+	err := r.db.WithContext(ctx).
+		Omit("content").
+		Preload("Editor", withDeletedUsers).
+		Where("post_id = ?", postID).
+		Order("version DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+func (r *postRepository) FindRevision(ctx context.Context, postID uint, version int) (*models.PostRevision, error) {
+	var revision models.PostRevision
+	err := r.db.WithContext(ctx).
+		Preload("Editor", withDeletedUsers).
+		Where("post_id = ? AND version = ?", postID, version).
+		First(&revision).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &revision, nil
+}
+
+func (r *postRepository) Delete(ctx context.Context, post *models.Post) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 并发删除同一篇文章时只有真正删除了记录的一方减少文章数
+		result := tx.Delete(post)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return addPostCount(tx, post.UserID, -1)
+	})
+}
+
+// addPostCount 调整作者的文章数，不修改 updated_at
+func addPostCount(tx *gorm.DB, userID uint, delta int) error {
+	return tx.Model(&models.User{}).Where("id = ?", userID).
+		UpdateColumn("post_count", gorm.Expr("post_count + ?", delta)).Error
+}
+
+func (r *postRepository) AppendTags(ctx context.Context, post *models.Post, tags []models.Tag) error {
+	return r.db.WithContext(ctx).Model(post).Association("Tags").Append(tags)
+}
+
+func (r *postRepository) RemoveTag(ctx context.Context, post *models.Post, tag *models.Tag) error {
+	return r.db.WithContext(ctx).Model(post).Association("Tags").Delete(tag)
+}
+
+func (r *postRepository) AddViews(ctx context.Context, counts map[uint]int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for id, n := range counts {
+			err := tx.Model(&models.Post{}).Where("id = ?", id).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", n)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *postRepository) PublishDue(ctx context.Context, now time.Time) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("status = ? AND publish_at <= ?", models.PostScheduled, now).Find(&posts).Error
+		if err != nil || len(posts) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(posts))
+		for i := range posts {
+			ids[i] = posts[i].ID
+			posts[i].Status = models.PostPublished
+		}
+		return tx.Model(&models.Post{}).
+			Where("id IN ? AND status = ?", ids, models.PostScheduled).
+			Update("status", models.PostPublished).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"blog/models"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReactionRepository 点赞和收藏数据访问接口。
+// 点赞、收藏都是幂等的，返回值表示这次调用是否真的改变了状态
+type ReactionRepository interface {
+	// Like 点赞并增加文章的点赞数，已点赞时返回 false
+	Like(ctx context.Context, userID, postID uint) (bool, error)
+	// Unlike 取消点赞并减少文章的点赞数，未点赞时返回 false
+	Unlike(ctx context.Context, userID, postID uint) (bool, error)
+	// Bookmark 收藏文章，已收藏时返回 false
+	Bookmark(ctx context.Context, userID, postID uint) (bool, error)
+	// Unbookmark 取消收藏，未收藏时返回 false
+	Unbookmark(ctx context.Context, userID, postID uint) (bool, error)
+	// Reactions 返回 postIDs 中 userID 已点赞和已收藏的文章
+	Reactions(ctx context.Context, userID uint, postIDs []uint) (liked, bookmarked map[uint]bool, err error)
+}
+
+type reactionRepository struct {
+	db *gorm.DB
+}
+
+// NewReactionRepository 创建基于 gorm 的 ReactionRepository
+func NewReactionRepository(db *gorm.DB) ReactionRepository {
+	return &reactionRepository{db: db}
+}
+
+func (r *reactionRepository) Like(ctx context.Context, userID, postID uint) (bool, error) {
+	var changed bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 重复点赞由主键冲突挡住，只有真正插入了记录才增加点赞数
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Like{UserID: userID, PostID: postID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		changed = true
+		return addLikeCount(tx, postID, 1)
+	})
+	return changed, err
+}
+
+func (r *reactionRepository) Unlike(ctx context.Context, userID, postID uint) (bool, error) {
+	var changed bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&models.Like{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		changed = true
+		return addLikeCount(tx, postID, -1)
+	})
+	return changed, err
+}
+
+// addLikeCount 调整文章的点赞数，不修改 updated_at
+func addLikeCount(tx *gorm.DB, postID uint, delta int) error {
+	return tx.Model(&models.Post{}).Where("id = ?", postID).
+		UpdateColumn("like_count", gorm.Expr("like_count + ?", delta)).Error
+}
+
+func (r *reactionRepository) Bookmark(ctx context.Context, userID, postID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Bookmark{UserID: userID, PostID: postID})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *reactionRepository) Unbookmark(ctx context.Context, userID, postID uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND post_id = ?", userID, postID).
+		Delete(&models.Bookmark{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *reactionRepository) Reactions(ctx context.Context, userID uint, postIDs []uint) (map[uint]bool, map[uint]bool, error) {
+	liked, err := r.reactedPosts(ctx, &models.Like{}, userID, postIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	bookmarked, err := r.reactedPosts(ctx, &models.Bookmark{}, userID, postIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return liked, bookmarked, nil
+}
+
+// reactedPosts 返回 postIDs 中 userID 在 model 对应的表里有记录的文章
+func (r *reactionRepository) reactedPosts(ctx context.Context, model interface{}, userID uint, postIDs []uint) (map[uint]bool, error) {
+	set := make(map[uint]bool)
+	if len(postIDs) == 0 {
+		return set, nil
+	}
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(model).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Pluck("post_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, nil
+}
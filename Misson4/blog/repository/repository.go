@@ -0,0 +1,24 @@
+// Package repository 封装对数据库的访问，handlers 和 service 通过这里的接口读写数据，
+// 不直接依赖 gorm 和全局的 database.DB。
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound 记录不存在
+	ErrNotFound = errors.New("record not found")
+	// ErrSlugTaken slug 已被其他文章使用
+	ErrSlugTaken = errors.New("slug already in use")
+)
+
+// translateError 把 gorm 的错误转换为仓储层错误
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
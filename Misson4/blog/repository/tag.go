@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"blog/models"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TagCount 标签及其下已发布文章的数量
+type TagCount struct {
+	models.Tag
+	PostCount int64 `json:"post_count"`
+}
+
+// CategoryCount 分类及其下已发布文章的数量
+type CategoryCount struct {
+	models.Category
+	PostCount int64 `json:"post_count"`
+}
+
+// TagRepository 标签数据访问接口
+type TagRepository interface {
+	// FindOrCreate 按名称查找标签，不存在的自动创建，结果按名称排序
+	FindOrCreate(ctx context.Context, names []string) ([]models.Tag, error)
+	FindByName(ctx context.Context, name string) (*models.Tag, error)
+	// ListWithCounts 返回至少有一篇已发布文章的标签，按文章数降序
+	ListWithCounts(ctx context.Context) ([]TagCount, error)
+}
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+// NewTagRepository 创建基于 gorm 的 TagRepository
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+func (r *tagRepository) FindOrCreate(ctx context.Context, names []string) ([]models.Tag, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	tags := make([]models.Tag, len(names))
+	for i, name := range names {
+		tags[i] = models.Tag{Name: name}
+	}
+	// 并发创建同名标签时由唯一索引兜底，之后统一按名称重新查询拿到 ID
+	db := r.db.WithContext(ctx)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	var found []models.Tag
+	if err := db.Where("name IN ?", names).Order("name").Find(&found).Error; err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+func (r *tagRepository) FindByName(ctx context.Context, name string) (*models.Tag, error) {
+	var tag models.Tag
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&tag).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &tag, nil
+}
+
+func (r *tagRepository) ListWithCounts(ctx context.Context) ([]TagCount, error) {
+	var tags []TagCount
+	err := r.db.WithContext(ctx).Model(&models.Tag{}).
+		Select("tags.*, COUNT(posts.id) AS post_count").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.deleted_at IS NULL AND posts.status = ?", models.PostPublished).
+		Group("tags.id").
+		Order("post_count DESC").
+		Order("tags.name").
+		Find(&tags).Error
+	return tags, err
+}
+
+// CategoryRepository 分类数据访问接口
+type CategoryRepository interface {
+	Create(ctx context.Context, category *models.Category) error
+	FindByID(ctx context.Context, id uint) (*models.Category, error)
+	FindByName(ctx context.Context, name string) (*models.Category, error)
+	// ListWithCounts 返回全部分类，按名称排序
+	ListWithCounts(ctx context.Context) ([]CategoryCount, error)
+}
+
+type categoryRepository struct {
+	db *gorm.DB
+}
+
+// NewCategoryRepository 创建基于 gorm 的 CategoryRepository
+func NewCategoryRepository(db *gorm.DB) CategoryRepository {
+	return &categoryRepository{db: db}
+}
+
+func (r *categoryRepository) Create(ctx context.Context, category *models.Category) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+func (r *categoryRepository) FindByID(ctx context.Context, id uint) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.WithContext(ctx).First(&category, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) FindByName(ctx context.Context, name string) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&category).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) ListWithCounts(ctx context.Context) ([]CategoryCount, error) {
+	var categories []CategoryCount
+	err := r.db.WithContext(ctx).Model(&models.Category{}).
+		Select("categories.*, COUNT(posts.id) AS post_count").
+		Joins("LEFT JOIN posts ON posts.category_id = categories.id AND posts.deleted_at IS NULL AND posts.status = ?", models.PostPublished).
+		Group("categories.id").
+		Order("categories.name").
+		Find(&categories).Error
+	return categories, err
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"blog/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TokenRepository 刷新令牌和访问令牌黑名单的数据访问接口
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// RevokeRefreshToken 作废一个尚未作废的刷新令牌，返回 false 表示它已经被作废（例如并发刷新）
+	RevokeRefreshToken(ctx context.Context, id uint, at time.Time) (bool, error)
+	// RevokeFamily 作废同一 family 下所有未作废的刷新令牌
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+	// RevokeUserRefreshTokens 作废用户所有未作废的刷新令牌
+	RevokeUserRefreshTokens(ctx context.Context, userID uint, at time.Time) error
+	// RevokeAccessToken 把访问令牌的 jti 加入黑名单，直到 expiresAt
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	// DeleteExpired 清理 before 之前过期的刷新令牌和黑名单记录
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+type tokenRepository struct {
+	db *gorm.DB
+}
+
+// NewTokenRepository 创建基于 gorm 的 TokenRepository
+func NewTokenRepository(db *gorm.DB) TokenRepository {
+	return &tokenRepository{db: db}
+}
+
+func (r *tokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *tokenRepository) FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &token, nil
+}
+
+func (r *tokenRepository) RevokeRefreshToken(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *tokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+func (r *tokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+func (r *tokenRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+}
+
+func (r *tokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *tokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ?", before).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("expires_at < ?", before).Delete(&models.RevokedToken{}).Error
+	})
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"blog/models"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// withDeletedUsers 加载文章、评论等内容的作者时包含已注销的用户，注销后保留的内容显示为匿名作者
+func withDeletedUsers(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// DeletedContent 注销账号时一并删除的内容
+type DeletedContent struct {
+	PostIDs    []uint
+	CommentIDs []uint
+}
+
+// UserRepository 用户数据访问接口
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	// List 按 ID 升序分页返回用户，同时返回用户总数
+	List(ctx context.Context, offset, limit int) ([]models.User, int64, error)
+	UpdateRole(ctx context.Context, id uint, role models.Role) error
+	// UpdateProfile 保存 user 的显示名称、简介和头像
+	UpdateProfile(ctx context.Context, user *models.User) error
+	// UpdatePassword 在用户密码哈希仍为 oldHash 时改为 newHash，返回 false 表示用户不存在或密码已被修改
+	UpdatePassword(ctx context.Context, id uint, oldHash, newHash string) (bool, error)
+	// MarkEmailVerified 在用户邮箱仍为 email 时记录验证时间，返回 false 表示用户不存在或邮箱已变化
+	MarkEmailVerified(ctx context.Context, id uint, email string, at time.Time) (bool, error)
+	// Delete 注销用户：清除用户名、邮箱、密码和个人资料后软删除，并删除其收藏。
+	// purge 为 false 时保留文章、评论和点赞，以匿名作者显示；
+	// 为 true 时同时删除其文章、评论和点赞，并同步文章的评论数和点赞数。
+	Delete(ctx context.Context, id uint, purge bool) (*DeletedContent, error)
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository 创建基于 gorm 的 UserRepository
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) List(ctx context.Context, offset, limit int) ([]models.User, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	if err := r.db.WithContext(ctx).Order("id").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id uint, role models.Role) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("role", role).Error
+}
+
+func (r *userRepository) UpdateProfile(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Model(user).Select("display_name", "bio", "avatar_url").Updates(user).Error
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint, oldHash, newHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND password = ?", id, oldHash).
+		Update("password", newHash)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uint, email string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND email = ?", id, email).
+		Update("email_verified_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *userRepository) Delete(ctx context.Context, id uint, purge bool) (*DeletedContent, error) {
+	deleted := &DeletedContent{}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 用户名和邮箱带有唯一索引，改为占位值以便重新注册
+		placeholder := fmt.Sprintf("deleted-%d", id)
+		result := tx.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"username":     placeholder,
+			"email":        placeholder + "@deleted.invalid",
+			"password":     "",
+			"display_name": "",
+			"bio":          "",
+			"avatar_url":   "",
+			"deleted_at":   time.Now(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.Bookmark{}).Error; err != nil {
+			return err
+		}
+		if !purge {
+			return nil
+		}
+
+		if err := deleteUserLikes(tx, id); err != nil {
+			return err
+		}
+		if err := deleteUserComments(tx, id, deleted); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Post{}).Where("user_id = ?", id).Pluck("id", &deleted.PostIDs).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", id).Delete(&models.Post{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// deleteUserLikes 删除用户的点赞并减少对应文章的点赞数
+func deleteUserLikes(tx *gorm.DB, userID uint) error {
+	var postIDs []uint
+	if err := tx.Model(&models.Like{}).Where("user_id = ?", userID).Pluck("post_id", &postIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.Like{}).Error; err != nil {
+		return err
+	}
+	for _, postID := range postIDs {
+		if err := addLikeCount(tx, postID, -1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteUserComments 软删除用户的评论并减少对应文章的评论数，删除的评论 ID 记录到 deleted
+func deleteUserComments(tx *gorm.DB, userID uint, deleted *DeletedContent) error {
+	var comments []models.Comment
+	if err := tx.Select("id", "post_id").Where("user_id = ?", userID).Find(&comments).Error; err != nil {
+		return err
+	}
+	if len(comments) == 0 {
+		return nil
+	}
+
+	perPost := make(map[uint]int)
+	for _, comment := range comments {
+		deleted.CommentIDs = append(deleted.CommentIDs, comment.ID)
+		perPost[comment.PostID]++
+	}
+	if err := tx.Where("id IN ?", deleted.CommentIDs).Delete(&models.Comment{}).Error; err != nil {
+		return err
+	}
+	for postID, n := range perPost {
+		if err := addCommentCount(tx, postID, -n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package repository_test
+
+import (
+	"blog/models"
+	"blog/repository"
+	"context"
+	"testing"
+)
+
+func TestUserUpdatePasswordChecksOldHash(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	repo := repository.NewUserRepository(db)
+
+	user := models.User{Username: "alice", Password: "old", Email: "alice@example.com"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 第二次使用同一旧哈希模拟同一重置令牌的并发请求，应当失败
+	tests := []struct {
+		name    string
+		id      uint
+		oldHash string
+		newHash string
+		want    bool
+	}{
+		{"matching hash", user.ID, "old", "new", true},
+		{"stale hash", user.ID, "old", "other", false},
+		{"unknown user", user.ID + 1, "new", "other", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.UpdatePassword(ctx, tt.id, tt.oldHash, tt.newHash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("UpdatePassword() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var got models.User
+	if err := db.First(&got, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.Password != "new" {
+		t.Errorf("password = %q, want %q", got.Password, "new")
+	}
+}
@@ -0,0 +1,83 @@
+// Package response 输出统一格式的 JSON 响应。
+// 成功时为 {"data": ..., "meta": ..., "request_id": "..."}，meta 只在列表等需要附加信息时出现；
+// 失败时为 {"error": {"code": "...", "message": "...", "details": [...]}, "request_id": "..."}。
+package response
+
+import (
+	"blog/apierror"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestIDKey 请求 ID 在 gin.Context 中的键
+const requestIDKey = "requestID"
+
+// Success 成功响应
+type Success struct {
+	Data      interface{} `json:"data"`
+	Meta      interface{} `json:"meta,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// Failure 失败响应
+type Failure struct {
+	Error     *apierror.Error `json:"error"`
+	RequestID string          `json:"request_id,omitempty"`
+}
+
+// SetRequestID 记录当前请求的 ID，由 RequestID 中间件调用
+func SetRequestID(c *gin.Context, id string) {
+	c.Set(requestIDKey, id)
+}
+
+// RequestID 返回当前请求的 ID
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// JSON 以 status 输出 data
+func JSON(c *gin.Context, status int, data interface{}) {
+	c.JSON(status, Success{Data: data, RequestID: RequestID(c)})
+}
+
+// OK 200 输出 data
+func OK(c *gin.Context, data interface{}) {
+	JSON(c, http.StatusOK, data)
+}
+
+// Created 201 输出新建的资源
+func Created(c *gin.Context, data interface{}) {
+	JSON(c, http.StatusCreated, data)
+}
+
+// List 200 输出列表和分页等附加信息，空列表输出为 []
+func List(c *gin.Context, data, meta interface{}) {
+	if v := reflect.ValueOf(data); v.Kind() == reflect.Slice && v.IsNil() {
+		data = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+	c.JSON(http.StatusOK, Success{Data: data, Meta: meta, RequestID: RequestID(c)})
+}
+
+// Accepted 202 请求已受理（如邮件已进入发送流程），data 为 null
+func Accepted(c *gin.Context) {
+	JSON(c, http.StatusAccepted, nil)
+}
+
+// NoContent 204 没有响应体
+func NoContent(c *gin.Context) {
+	c.Status(http.StatusNoContent)
+}
+
+// Error 记录错误并中止后续 handler，由 ErrorHandler 中间件统一输出。
+// 调用后 handler 应直接返回，不要再写入响应
+func Error(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// WriteError 立即输出错误响应，只由 ErrorHandler 中间件使用
+func WriteError(c *gin.Context, err *apierror.Error) {
+	c.AbortWithStatusJSON(err.Status, Failure{Error: err, RequestID: RequestID(c)})
+}
@@ -0,0 +1,238 @@
+package search
+
+import (
+	"blog/models"
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// titleWeight 标题中的词按出现 titleWeight 次计算，使标题命中排在前面
+	titleWeight = 3
+)
+
+type docKey struct {
+	kind string
+	id   uint
+}
+
+type document struct {
+	postID  uint
+	title   string
+	content string
+	length  float64
+}
+
+// MemoryIndex 进程内的倒排索引，使用 BM25 计算相关度，用于没有全文索引的数据库。
+// 只适合单实例部署：多个实例之间的索引不会同步。
+type MemoryIndex struct {
+	mu        sync.RWMutex
+	docs      map[docKey]*document
+	postings  map[string]map[docKey]float64 // 词 -> 文档 -> 加权词频
+	comments  map[uint]map[uint]bool        // 文章 ID -> 评论 ID，删除文章时一并删除评论
+	hidden    map[uint]bool                 // 未发布的文章 ID，文章本身不入索引，其评论不出现在结果中
+	totalSize float64
+}
+
+// NewMemoryIndex 创建空的内存索引
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     make(map[docKey]*document),
+		postings: make(map[string]map[docKey]float64),
+		comments: make(map[uint]map[uint]bool),
+		hidden:   make(map[uint]bool),
+	}
+}
+
+// Load 从数据库分批加载所有未删除的文章和评论。
+// 已删除文章下的评论不会被单独删除，只加载所属文章未删除的评论，与运行时 RemovePost 的结果一致
+func (m *MemoryIndex) Load(ctx context.Context, db *gorm.DB) error {
+	var posts []models.Post
+	err := db.WithContext(ctx).FindInBatches(&posts, 500, func(tx *gorm.DB, batch int) error {
+		for i := range posts {
+			m.IndexPost(&posts[i])
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	var comments []models.Comment
+	return db.WithContext(ctx).Model(&models.Comment{}).Select("comments.*").
+		Joins("JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL").
+		FindInBatches(&comments, 500, func(tx *gorm.DB, batch int) error {
+			for i := range comments {
+				m.IndexComment(&comments[i])
+			}
+			return nil
+		}).Error
+}
+
+// IndexPost 添加或更新文章，未发布的文章从索引中移除
+func (m *MemoryIndex) IndexPost(post *models.Post) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := docKey{TypePost, post.ID}
+	m.remove(key)
+	if post.Status != models.PostPublished {
+		m.hidden[post.ID] = true
+		return
+	}
+	delete(m.hidden, post.ID)
+	m.add(key, &document{postID: post.ID, title: post.Title, content: post.Content}, post.Title, post.Content)
+}
+
+// RemovePost 删除文章及其评论
+func (m *MemoryIndex) RemovePost(id uint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(docKey{TypePost, id})
+	for commentID := range m.comments[id] {
+		m.remove(docKey{TypeComment, commentID})
+	}
+	delete(m.comments, id)
+	delete(m.hidden, id)
+}
+
+// IndexComment 添加或更新评论
+func (m *MemoryIndex) IndexComment(comment *models.Comment) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := docKey{TypeComment, comment.ID}
+	m.remove(key)
+	m.add(key, &document{postID: comment.PostID, content: comment.Content}, "", comment.Content)
+
+	if m.comments[comment.PostID] == nil {
+		m.comments[comment.PostID] = make(map[uint]bool)
+	}
+	m.comments[comment.PostID][comment.ID] = true
+}
+
+// RemoveComment 删除评论
+func (m *MemoryIndex) RemoveComment(id uint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := docKey{TypeComment, id}
+	if doc, ok := m.docs[key]; ok {
+		delete(m.comments[doc.postID], id)
+	}
+	m.remove(key)
+}
+
+// Search 按 BM25 计算相关度，命中查询词越多的文档排名越靠前
+func (m *MemoryIndex) Search(ctx context.Context, q Query) ([]Result, error) {
+	terms := uniqueTokens(q.Text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	n := float64(len(m.docs))
+	avgSize := 1.0
+	if n > 0 && m.totalSize > 0 {
+		avgSize = m.totalSize / n
+	}
+
+	scores := make(map[docKey]float64)
+	matched := make(map[docKey]int)
+	for _, term := range terms {
+		postings := m.postings[term]
+		df := float64(len(postings))
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for key, tf := range postings {
+			if q.Type != "" && key.kind != q.Type {
+				continue
+			}
+			if m.hidden[m.docs[key].postID] {
+				continue
+			}
+			size := m.docs[key].length
+			scores[key] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*size/avgSize))
+			matched[key]++
+		}
+	}
+
+	results := make([]Result, 0, len(scores))
+	for key, score := range scores {
+		coverage := float64(matched[key]) / float64(len(terms))
+		doc := m.docs[key]
+		r := Result{
+			Type:    key.kind,
+			ID:      key.id,
+			PostID:  doc.postID,
+			Snippet: Snippet(doc.content, terms),
+			Score:   score * coverage * coverage,
+		}
+		if post, ok := m.docs[docKey{TypePost, doc.postID}]; ok {
+			r.PostTitle = post.title
+		}
+		results = append(results, r)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID > results[j].ID
+	})
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+// add 建立文档的倒排记录，调用方需持有写锁
+func (m *MemoryIndex) add(key docKey, doc *document, title, content string) {
+	freq := make(map[string]float64)
+	for _, t := range tokenize(title) {
+		freq[t] += titleWeight
+		doc.length += titleWeight
+	}
+	for _, t := range tokenize(content) {
+		freq[t]++
+		doc.length++
+	}
+
+	for term, tf := range freq {
+		if m.postings[term] == nil {
+			m.postings[term] = make(map[docKey]float64)
+		}
+		m.postings[term][key] = tf
+	}
+	m.docs[key] = doc
+	m.totalSize += doc.length
+}
+
+// remove 删除文档的倒排记录，调用方需持有写锁
+func (m *MemoryIndex) remove(key docKey) {
+	doc, ok := m.docs[key]
+	if !ok {
+		return
+	}
+
+	for _, t := range uniqueTokens(doc.title + " " + doc.content) {
+		delete(m.postings[t], key)
+		if len(m.postings[t]) == 0 {
+			delete(m.postings, t)
+		}
+	}
+	m.totalSize -= doc.length
+	delete(m.docs, key)
+}
@@ -0,0 +1,157 @@
+package search_test
+
+import (
+	"blog/config"
+	"blog/database"
+	"blog/models"
+	"blog/search"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// openDB 打开迁移到最新版本的内存 SQLite 数据库
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(config.DatabaseConfig{Driver: config.DriverSQLite, DSN: ":memory:"}, config.LogLevelError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestMemoryIndexLoadSkipsCommentsOfDeletedPosts(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+
+	user := models.User{Username: "alice", Password: "x", Email: "alice@example.com"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	live := models.Post{Title: "live", Slug: "live", Content: "live post", UserID: user.ID, Status: models.PostPublished}
+	deleted := models.Post{Title: "gone", Slug: "gone", Content: "gone post", UserID: user.ID, Status: models.PostPublished}
+	if err := db.Create(&live).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	comments := []models.Comment{
+		{Content: "marmalade on live", UserID: user.ID, PostID: live.ID},
+		{Content: "marmalade on gone", UserID: user.ID, PostID: deleted.ID},
+	}
+	if err := db.Create(&comments).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	index := search.NewMemoryIndex()
+	if err := index.Load(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := index.Search(ctx, search.Query{Text: "marmalade"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ID != comments[0].ID {
+		t.Fatalf("Search() = %+v, want only comment %d", results, comments[0].ID)
+	}
+}
+
+// newIndex 建立一个包含三篇文章（其中一篇是草稿）和三条评论的内存索引
+func newIndex() *search.MemoryIndex {
+	index := search.NewMemoryIndex()
+	index.IndexPost(&models.Post{ID: 1, Title: "Gopher guide", Content: "channels and goroutines", Status: models.PostPublished})
+	index.IndexPost(&models.Post{ID: 2, Title: "Cooking", Content: "bread with butter and a gopher sticker", Status: models.PostPublished})
+	index.IndexPost(&models.Post{ID: 3, Title: "Draft gopher", Content: "secret goroutines", Status: models.PostDraft})
+	index.IndexComment(&models.Comment{ID: 10, PostID: 1, Content: "great goroutines tips"})
+	index.IndexComment(&models.Comment{ID: 11, PostID: 2, Content: "butter is tasty"})
+	index.IndexComment(&models.Comment{ID: 12, PostID: 3, Content: "butter goroutines"})
+	return index
+}
+
+// resultKeys 把结果转成 "post:1" 形式，便于比较
+func resultKeys(results []search.Result) []string {
+	keys := make([]string, 0, len(results))
+	for _, r := range results {
+		keys = append(keys, fmt.Sprintf("%s:%d", r.Type, r.ID))
+	}
+	return keys
+}
+
+func TestMemoryIndexSearch(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(index *search.MemoryIndex)
+		query  search.Query
+		want   []string
+	}{
+		{"empty query", nil, search.Query{Text: "  "}, []string{}},
+		{"no match", nil, search.Query{Text: "kotlin"}, []string{}},
+		{"title ranks first", nil, search.Query{Text: "gopher"}, []string{"post:1", "post:2"}},
+		{"case insensitive", nil, search.Query{Text: "GOROUTINES"}, []string{"comment:10", "post:1"}},
+		{"posts only", nil, search.Query{Text: "goroutines", Type: search.TypePost}, []string{"post:1"}},
+		{"comments only", nil, search.Query{Text: "butter", Type: search.TypeComment}, []string{"comment:11"}},
+		{"limit", nil, search.Query{Text: "butter", Limit: 1}, []string{"comment:11"}},
+		{"removed comment", func(index *search.MemoryIndex) { index.RemoveComment(10) },
+			search.Query{Text: "goroutines"}, []string{"post:1"}},
+		{"removed post drops its comments", func(index *search.MemoryIndex) { index.RemovePost(2) },
+			search.Query{Text: "butter"}, []string{}},
+		{"unpublished post hides its comments", func(index *search.MemoryIndex) {
+			index.IndexPost(&models.Post{ID: 1, Title: "Gopher guide", Content: "channels and goroutines", Status: models.PostDraft})
+		}, search.Query{Text: "goroutines"}, []string{}},
+		{"published draft becomes visible", func(index *search.MemoryIndex) {
+			index.IndexPost(&models.Post{ID: 3, Title: "Draft gopher", Content: "secret goroutines", Status: models.PostPublished})
+		}, search.Query{Text: "secret"}, []string{"post:3"}},
+		{"updated post", func(index *search.MemoryIndex) {
+			index.IndexPost(&models.Post{ID: 1, Title: "Rust guide", Content: "ownership", Status: models.PostPublished})
+		}, search.Query{Text: "gopher"}, []string{"post:2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := newIndex()
+			if tt.change != nil {
+				tt.change(index)
+			}
+			results, err := index.Search(context.Background(), tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resultKeys(results); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%+v) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryIndexResultFields(t *testing.T) {
+	results, err := newIndex().Search(context.Background(), search.Query{Text: "tips"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Search() = %+v, want one result", results)
+	}
+	r := results[0]
+	if r.PostID != 1 || r.PostTitle != "Gopher guide" || r.Score <= 0 {
+		t.Errorf("result = %+v, want post 1 with its title and a positive score", r)
+	}
+	if !strings.Contains(r.Snippet, "<mark>tips</mark>") {
+		t.Errorf("Snippet = %q, want the term highlighted", r.Snippet)
+	}
+}
@@ -0,0 +1,100 @@
+package search
+
+import (
+	"blog/models"
+	"context"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// MySQL 基于 FULLTEXT 索引的搜索，索引由迁移创建，随数据库自动维护
+type MySQL struct {
+	db *gorm.DB
+}
+
+// NewMySQL 创建基于 MySQL FULLTEXT 索引的搜索
+func NewMySQL(db *gorm.DB) *MySQL {
+	return &MySQL{db: db}
+}
+
+// IndexPost MySQL 自动维护索引，无需操作
+func (s *MySQL) IndexPost(post *models.Post) {}
+
+// RemovePost MySQL 自动维护索引，无需操作
+func (s *MySQL) RemovePost(id uint) {}
+
+// IndexComment MySQL 自动维护索引，无需操作
+func (s *MySQL) IndexComment(comment *models.Comment) {}
+
+// RemoveComment MySQL 自动维护索引，无需操作
+func (s *MySQL) RemoveComment(id uint) {}
+
+type mysqlRow struct {
+	ID        uint
+	PostID    uint
+	PostTitle string
+	Content   string
+	Score     float64
+}
+
+// Search 使用自然语言模式的 MATCH ... AGAINST 计算相关度，只搜索已发布的文章及其评论
+func (s *MySQL) Search(ctx context.Context, q Query) ([]Result, error) {
+	text := normalizeQuery(q.Text)
+	if text == "" {
+		return nil, nil
+	}
+	terms := uniqueTokens(text)
+
+	var results []Result
+	if q.Type == "" || q.Type == TypePost {
+		var rows []mysqlRow
+		err := s.db.WithContext(ctx).Raw(`
+			SELECT id, id AS post_id, title AS post_title, content,
+			       MATCH(title, content) AGAINST (? IN NATURAL LANGUAGE MODE) AS score
+			FROM posts
+			WHERE deleted_at IS NULL AND status = 'published' AND MATCH(title, content) AGAINST (? IN NATURAL LANGUAGE MODE)
+			ORDER BY score DESC
+			LIMIT ?`, text, text, q.Limit).Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		results = appendRows(results, TypePost, rows, terms)
+	}
+
+	if q.Type == "" || q.Type == TypeComment {
+		var rows []mysqlRow
+		err := s.db.WithContext(ctx).Raw(`
+			SELECT comments.id, comments.post_id, posts.title AS post_title, comments.content,
+			       MATCH(comments.content) AGAINST (? IN NATURAL LANGUAGE MODE) AS score
+			FROM comments
+			JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL AND posts.status = 'published'
+			WHERE comments.deleted_at IS NULL AND MATCH(comments.content) AGAINST (? IN NATURAL LANGUAGE MODE)
+			ORDER BY score DESC
+			LIMIT ?`, text, text, q.Limit).Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		results = appendRows(results, TypeComment, rows, terms)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+func appendRows(results []Result, kind string, rows []mysqlRow, terms []string) []Result {
+	for _, row := range rows {
+		results = append(results, Result{
+			Type:      kind,
+			ID:        row.ID,
+			PostID:    row.PostID,
+			PostTitle: row.PostTitle,
+			Snippet:   Snippet(row.Content, terms),
+			Score:     row.Score,
+		})
+	}
+	return results
+}
@@ -0,0 +1,66 @@
+// Package search 实现文章和评论的全文搜索。
+// MySQL 下使用 FULLTEXT 索引（ngram 分词，支持中文），其他数据库使用进程内的倒排索引。
+package search
+
+import (
+	"blog/config"
+	"blog/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// 结果类型
+const (
+	TypePost    = "post"
+	TypeComment = "comment"
+)
+
+// Result 一条搜索结果，Snippet 为 HTML 转义后的摘要，命中的词用 <mark> 包裹
+type Result struct {
+	Type      string  `json:"type"`
+	ID        uint    `json:"id"`
+	PostID    uint    `json:"post_id"`
+	PostTitle string  `json:"post_title"`
+	Snippet   string  `json:"snippet"`
+	Score     float64 `json:"score"`
+}
+
+// Query 搜索条件，Type 为空表示文章和评论都搜索
+type Query struct {
+	Text  string
+	Type  string
+	Limit int
+}
+
+// Searcher 执行搜索，结果按相关度降序
+type Searcher interface {
+	Search(ctx context.Context, q Query) ([]Result, error)
+}
+
+// Indexer 在文章和评论变化时更新索引；依赖数据库索引的实现可以什么都不做
+type Indexer interface {
+	IndexPost(post *models.Post)
+	RemovePost(id uint)
+	IndexComment(comment *models.Comment)
+	RemoveComment(id uint)
+}
+
+// Engine 同时提供搜索和索引维护
+type Engine interface {
+	Searcher
+	Indexer
+}
+
+// New 根据数据库驱动选择搜索实现。非 MySQL 驱动时从数据库加载全部文章和评论建立内存索引。
+func New(ctx context.Context, driver string, db *gorm.DB) (Engine, error) {
+	if driver == config.DriverMySQL {
+		return NewMySQL(db), nil
+	}
+
+	index := NewMemoryIndex()
+	if err := index.Load(ctx, db); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
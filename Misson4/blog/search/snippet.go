@@ -0,0 +1,94 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// snippetLength 摘要的最大长度（字符数）
+const snippetLength = 160
+
+// snippetLead 第一个命中词之前保留的上下文长度（字符数）
+const snippetLead = 40
+
+// Snippet 截取 text 中第一个命中词附近的片段，HTML 转义后用 <mark> 标出所有命中的词
+func Snippet(text string, terms []string) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	// 找出所有命中区间
+	type span struct{ start, end int }
+	var spans []span
+	for _, term := range terms {
+		t := []rune(term)
+		if len(t) == 0 {
+			continue
+		}
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if runesEqual(lower[i:i+len(t)], t) {
+				spans = append(spans, span{i, i + len(t)})
+			}
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	// 合并重叠的区间（中文按两字切分，相邻的词会重叠）
+	var merged []span
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s.start <= merged[n-1].end {
+			if s.end > merged[n-1].end {
+				merged[n-1].end = s.end
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	start := 0
+	if len(merged) > 0 && merged[0].start > snippetLead {
+		start = merged[0].start - snippetLead
+	}
+	end := start + snippetLength
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, s := range merged {
+		if s.end <= start {
+			continue
+		}
+		if s.start >= end {
+			break
+		}
+		ms, me := max(s.start, start), min(s.end, end)
+		b.WriteString(html.EscapeString(string(runes[pos:ms])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[ms:me])))
+		b.WriteString("</mark>")
+		pos = me
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
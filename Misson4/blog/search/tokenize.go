@@ -0,0 +1,76 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenize 把文本切分为索引词：
+// 字母和数字组成的连续片段整体作为一个词（小写），
+// 中日韩文字没有空格分词，按相邻两个字切分（单字片段保留单字）。
+func tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			tokens = append(tokens, string(cjk))
+		case len(cjk) > 1:
+			for i := 0; i+1 < len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, unicode.ToLower(r))
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+// uniqueTokens 返回去重后的索引词，保持首次出现的顺序
+func uniqueTokens(text string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, t := range tokenize(text) {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// isCJK 是否为中日韩文字
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
+
+// normalizeQuery 压缩查询中的空白
+func normalizeQuery(q string) string {
+	return strings.Join(strings.Fields(q), " ")
+}
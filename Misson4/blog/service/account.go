@@ -0,0 +1,186 @@
+package service
+
+import (
+	"blog/models"
+	"blog/repository"
+	"blog/search"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 个人资料字段的最大长度（字符），与 users 表的列宽一致
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 500
+	maxAvatarURLLength   = 255
+)
+
+// ProfileInput 修改个人资料参数，为 nil 的字段不修改，空字符串表示清空
+type ProfileInput struct {
+	DisplayName *string
+	Bio         *string
+	AvatarURL   *string
+}
+
+// Session 发起请求的登录会话，修改密码和注销账号时用于注销当前访问令牌
+type Session struct {
+	UserID          uint
+	JTI             string
+	AccessExpiresAt time.Time
+}
+
+// AccountService 当前用户的个人资料、密码和账号注销
+type AccountService struct {
+	users   repository.UserRepository
+	tokens  *TokenService
+	indexer search.Indexer
+}
+
+// NewAccountService 创建 AccountService，注销账号并删除内容时同步更新 indexer
+func NewAccountService(users repository.UserRepository, tokens *TokenService, indexer search.Indexer) *AccountService {
+	return &AccountService{users: users, tokens: tokens, indexer: indexer}
+}
+
+// Get 返回用户，不存在或已注销时返回 ErrUserNotFound
+func (s *AccountService) Get(ctx context.Context, id uint) (*models.User, error) {
+	user, err := s.users.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// UpdateProfile 修改个人资料，内容过长或头像地址无效时返回 ErrInvalidProfile
+func (s *AccountService) UpdateProfile(ctx context.Context, id uint, in ProfileInput) (*models.User, error) {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.DisplayName != nil {
+		user.DisplayName = strings.TrimSpace(*in.DisplayName)
+	}
+	if in.Bio != nil {
+		user.Bio = strings.TrimSpace(*in.Bio)
+	}
+	if in.AvatarURL != nil {
+		user.AvatarURL = strings.TrimSpace(*in.AvatarURL)
+	}
+	if err := validateProfile(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.users.UpdateProfile(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// validateProfile 检查个人资料的长度和头像地址
+func validateProfile(user *models.User) error {
+	if utf8.RuneCountInString(user.DisplayName) > maxDisplayNameLength {
+		return &FieldError{Err: ErrInvalidProfile, Field: "display_name", Rule: "max",
+			Message: fmt.Sprintf("display_name is longer than %d characters", maxDisplayNameLength)}
+	}
+	if utf8.RuneCountInString(user.Bio) > maxBioLength {
+		return &FieldError{Err: ErrInvalidProfile, Field: "bio", Rule: "max",
+			Message: fmt.Sprintf("bio is longer than %d characters", maxBioLength)}
+	}
+	if len(user.AvatarURL) > maxAvatarURLLength {
+		return &FieldError{Err: ErrInvalidProfile, Field: "avatar_url", Rule: "max",
+			Message: fmt.Sprintf("avatar_url is longer than %d bytes", maxAvatarURLLength)}
+	}
+	if user.AvatarURL != "" && !validAvatarURL(user.AvatarURL) {
+		return &FieldError{Err: ErrInvalidProfile, Field: "avatar_url", Rule: "url",
+			Message: "avatar_url must be an http(s) URL or a path starting with /"}
+	}
+
+	return nil
+}
+
+// validAvatarURL 头像可以是上传后得到的站内路径，也可以是外部的 http(s) 地址
+func validAvatarURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" {
+		return u.Host == "" && strings.HasPrefix(u.Path, "/")
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// ChangePassword 校验当前密码后修改密码。其他登录会话随之失效，
+// 当前会话的访问令牌也会被注销，返回为当前会话重新签发的令牌。
+// 当前密码错误时返回 ErrWrongPassword。
+func (s *AccountService) ChangePassword(ctx context.Context, session Session, oldPassword, newPassword string) (*TokenPair, error) {
+	user, err := s.checkPassword(ctx, session.UserID, oldPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("hash password: %w", err)
+	}
+	// 校验之后密码被其他请求修改时，当前密码已不再正确
+	updated, err := s.users.UpdatePassword(ctx, user.ID, user.Password, string(hash))
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrWrongPassword
+	}
+
+	if err := s.tokens.LogoutAll(ctx, user.ID, session.JTI, session.AccessExpiresAt); err != nil {
+		return nil, err
+	}
+	return s.tokens.Issue(ctx, user)
+}
+
+// Delete 校验密码后注销账号并作废所有刷新令牌，已签发的访问令牌由认证中间件拒绝。purge 为 false 时保留文章和评论并匿名显示作者，
+// 为 true 时一并删除其文章、评论和点赞。管理员不能注销自己的账号，避免系统失去最后一个管理员。
+func (s *AccountService) Delete(ctx context.Context, session Session, password string, purge bool) error {
+	user, err := s.checkPassword(ctx, session.UserID, password)
+	if err != nil {
+		return err
+	}
+	if user.Role == models.RoleAdmin {
+		return ErrAdminAccount
+	}
+
+	deleted, err := s.users.Delete(ctx, user.ID, purge)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	for _, id := range deleted.PostIDs {
+		s.indexer.RemovePost(id)
+	}
+	for _, id := range deleted.CommentIDs {
+		s.indexer.RemoveComment(id)
+	}
+
+	return s.tokens.LogoutAll(ctx, user.ID, session.JTI, session.AccessExpiresAt)
+}
+
+// checkPassword 查找用户并校验密码，密码错误时返回 ErrWrongPassword
+func (s *AccountService) checkPassword(ctx context.Context, id uint, password string) (*models.User, error) {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, ErrWrongPassword
+	}
+	return user, nil
+}
@@ -0,0 +1,14 @@
+package service
+
+import "blog/models"
+
+// Actor 发起操作的已登录用户
+type Actor struct {
+	ID   uint
+	Role models.Role
+}
+
+// Can 是否拥有权限
+func (a Actor) Can(p models.Permission) bool {
+	return a.Role.Can(p)
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"blog/models"
+	"blog/repository"
+	"blog/storage"
+	"blog/thumbnail"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// allowedTypes 允许上传的文件类型及保存时使用的扩展名。
+// 类型根据文件内容判断，不信任客户端声明的 Content-Type 和文件名
+var allowedTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+}
+
+// maxFilenameLength 保存的原始文件名的最大长度（字节）
+const maxFilenameLength = 255
+
+// UploadInput 上传文件参数，PostID 不为空时上传后直接关联到该文章
+type UploadInput struct {
+	Filename string
+	Body     io.Reader
+	PostID   *uint
+}
+
+// AttachmentService 图片和附件的上传、关联和删除
+type AttachmentService struct {
+	attachments repository.AttachmentRepository
+	posts       repository.PostRepository
+	store       storage.Storage
+	maxSize     int64
+}
+
+// NewAttachmentService 创建 AttachmentService，maxSize 为单个文件的大小上限（字节）
+func NewAttachmentService(attachments repository.AttachmentRepository, posts repository.PostRepository, store storage.Storage, maxSize int64) *AttachmentService {
+	return &AttachmentService{attachments: attachments, posts: posts, store: store, maxSize: maxSize}
+}
+
+// MaxSize 单个文件的大小上限（字节）
+func (s *AttachmentService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Upload 校验并保存上传的文件，图片同时生成缩略图。
+// 超过大小上限返回 ErrFileTooLarge，类型不在白名单中或图片无法解码返回 ErrUnsupportedFile；
+// 指定 PostID 时权限同修改文章。
+func (s *AttachmentService) Upload(ctx context.Context, actor Actor, in UploadInput) (*models.Attachment, error) {
+	if in.PostID != nil {
+		if _, err := findManagedPost(ctx, s.posts, actor, *in.PostID); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(in.Body, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, ErrFileTooLarge
+	}
+	contentType, ext, ok := detectType(data)
+	if !ok {
+		return nil, ErrUnsupportedFile
+	}
+
+	key, err := newStorageKey(time.Now(), ext)
+	if err != nil {
+		return nil, err
+	}
+	attachment := &models.Attachment{
+		UserID:      actor.ID,
+		PostID:      in.PostID,
+		Filename:    cleanFilename(in.Filename, ext),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		StorageKey:  key,
+		URL:         s.store.URL(key),
+	}
+
+	var thumb *thumbnail.Thumbnail
+	if strings.HasPrefix(contentType, "image/") {
+		thumb, err = thumbnail.Make(bytes.NewReader(data))
+		if errors.Is(err, thumbnail.ErrTooLarge) {
+			return nil, ErrFileTooLarge
+		}
+		if err != nil {
+			return nil, ErrUnsupportedFile
+		}
+		attachment.Width, attachment.Height = thumb.Width, thumb.Height
+		attachment.ThumbnailKey = strings.TrimSuffix(key, ext) + "_thumb" + allowedTypes[thumb.ContentType]
+		attachment.ThumbnailURL = s.store.URL(attachment.ThumbnailKey)
+	}
+
+	if err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return nil, err
+	}
+	if thumb != nil {
+		err := s.store.Put(ctx, attachment.ThumbnailKey, bytes.NewReader(thumb.Data), int64(len(thumb.Data)), thumb.ContentType)
+		if err != nil {
+			return nil, errors.Join(err, s.removeFiles(ctx, attachment))
+		}
+	}
+	if err := s.attachments.Create(ctx, attachment); err != nil {
+		return nil, errors.Join(err, s.removeFiles(ctx, attachment))
+	}
+	return attachment, nil
+}
+
+// Link 把附件关联到文章。只有上传者可以关联自己的附件，对文章的权限同修改文章
+func (s *AttachmentService) Link(ctx context.Context, actor Actor, id, postID uint) (*models.Attachment, error) {
+	attachment, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.UserID != actor.ID {
+		return nil, ErrForbidden
+	}
+	if _, err := findManagedPost(ctx, s.posts, actor, postID); err != nil {
+		return nil, err
+	}
+
+	if err := s.attachments.LinkToPost(ctx, []uint{id}, postID); err != nil {
+		return nil, err
+	}
+	attachment.PostID = &postID
+	return attachment, nil
+}
+
+// ListByPost 返回已发布文章的附件，未发布的文章视为不存在
+func (s *AttachmentService) ListByPost(ctx context.Context, postID uint) ([]models.Attachment, error) {
+	post, err := findPublishedPost(ctx, s.posts, postID)
+	if err != nil {
+		return nil, err
+	}
+	return post.Attachments, nil
+}
+
+// Delete 删除附件及其文件，上传者和拥有 post:moderate 权限的用户可以删除
+func (s *AttachmentService) Delete(ctx context.Context, actor Actor, id uint) error {
+	attachment, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if attachment.UserID != actor.ID && !actor.Can(models.PermPostModerate) {
+		return ErrForbidden
+	}
+
+	// 先删文件再删记录：删除文件失败时记录还在，可以重试
+	if err := s.removeFiles(ctx, attachment); err != nil {
+		return err
+	}
+	return s.attachments.Delete(ctx, attachment)
+}
+
+// find 查找附件，不存在时返回 ErrAttachmentNotFound
+func (s *AttachmentService) find(ctx context.Context, id uint) (*models.Attachment, error) {
+	attachment, err := s.attachments.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrAttachmentNotFound
+	}
+	return attachment, err
+}
+
+// removeFiles 删除附件的原文件和缩略图
+func (s *AttachmentService) removeFiles(ctx context.Context, attachment *models.Attachment) error {
+	err := s.store.Delete(ctx, attachment.StorageKey)
+	if attachment.ThumbnailKey != "" {
+		err = errors.Join(err, s.store.Delete(ctx, attachment.ThumbnailKey))
+	}
+	return err
+}
+
+// detectType 根据文件内容判断类型，返回去掉参数的 MIME 类型和对应的扩展名
+func detectType(data []byte) (contentType, ext string, ok bool) {
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "", "", false
+	}
+	ext, ok = allowedTypes[contentType]
+	return contentType, ext, ok
+}
+
+// newStorageKey 生成按年月分目录的随机 key，例如 2024/06/3q2-7wEjdR0r0lH9fFzXxw.jpg
+func newStorageKey(now time.Time, ext string) (string, error) {
+	name, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	return now.UTC().Format("2006/01/") + name + ext, nil
+}
+
+// cleanFilename 去掉客户端文件名中的路径并限制长度，为空时以 file 加扩展名代替
+func cleanFilename(name, ext string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "file" + ext
+	}
+	if len(name) > maxFilenameLength {
+		name = name[:maxFilenameLength]
+		for !utf8.ValidString(name) {
+			name = name[:len(name)-1]
+		}
+	}
+	return name
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"blog/markdown"
+	"blog/models"
+	"blog/repository"
+	"blog/search"
+	"context"
+	"errors"
+	"time"
+)
+
+// 评论分页默认值
+const (
+	DefaultCommentLimit = 20
+	MaxCommentLimit     = 100
+)
+
+// CreateCommentInput 创建评论参数
+type CreateCommentInput struct {
+	Content  string
+	ParentID *uint // 回复的评论，为空表示顶级评论
+}
+
+// CommentNode 评论树中的一个节点，Depth 从 0（顶级评论）开始。
+// 已删除但仍有回复的评论以 Deleted 占位，内容和作者被清空。
+type CommentNode struct {
+	models.Comment
+	Deleted bool           `json:"deleted,omitempty"`
+	Depth   int            `json:"depth"`
+	Replies []*CommentNode `json:"replies,omitempty"`
+}
+
+// newCommentNode 创建树节点，已删除的评论隐藏内容和作者
+func newCommentNode(c models.Comment, depth int) *CommentNode {
+	node := &CommentNode{Comment: c, Depth: depth}
+	if c.DeletedAt.Valid {
+		node.Deleted = true
+		node.Content = ""
+		node.ContentHTML = ""
+		node.UserID = 0
+		node.User = models.User{}
+	}
+	return node
+}
+
+// CommentPage 一页顶级评论（各自带完整的回复树）及分页信息
+type CommentPage struct {
+	Comments   []*CommentNode
+	Total      int64 // 顶级评论总数
+	Limit      int
+	NextCursor string // 没有下一页时为空
+}
+
+// commentCursor 顶级评论游标的序列化形式
+type commentCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uint      `json:"id"`
+}
+
+// CommentService 评论业务逻辑
+type CommentService struct {
+	comments repository.CommentRepository
+	posts    repository.PostRepository
+	indexer  search.Indexer
+}
+
+// NewCommentService 创建 CommentService，评论变化时同步更新 indexer
+func NewCommentService(comments repository.CommentRepository, posts repository.PostRepository, indexer search.Indexer) *CommentService {
+	return &CommentService{comments: comments, posts: posts, indexer: indexer}
+}
+
+// Create 在文章下创建评论或回复。文章不存在时返回 ErrPostNotFound，
+// 被回复的评论不存在或不属于该文章时返回 ErrInvalidParent。
+func (s *CommentService) Create(ctx context.Context, userID, postID uint, in CreateCommentInput) (*models.Comment, error) {
+	if err := s.ensurePost(ctx, postID); err != nil {
+		return nil, err
+	}
+
+	if in.ParentID != nil {
+		parent, err := s.comments.FindByID(ctx, *in.ParentID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidParent
+		}
+		if err != nil {
+			return nil, err
+		}
+		if parent.PostID != postID {
+			return nil, ErrInvalidParent
+		}
+	}
+
+	comment := &models.Comment{
+		Content:     in.Content,
+		ContentHTML: markdown.Render(in.Content),
+		UserID:      userID,
+		PostID:      postID,
+		ParentID:    in.ParentID,
+	}
+	if err := s.comments.Create(ctx, comment); err != nil {
+		return nil, err
+	}
+	s.indexer.IndexComment(comment)
+	return s.comments.FindByID(ctx, comment.ID)
+}
+
+// Update 修改评论内容并记录编辑时间，只有评论作者可以修改
+func (s *CommentService) Update(ctx context.Context, actor Actor, id uint, content string) (*models.Comment, error) {
+	comment, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != actor.ID {
+		return nil, ErrForbidden
+	}
+
+	now := time.Now()
+	comment.Content = content
+	comment.ContentHTML = markdown.Render(content)
+	comment.EditedAt = &now
+	if err := s.comments.Update(ctx, comment); err != nil {
+		return nil, err
+	}
+	s.indexer.IndexComment(comment)
+	return s.comments.FindByID(ctx, comment.ID)
+}
+
+// Delete 软删除评论，评论作者、所在文章的作者和拥有 comment:moderate 权限的用户可以删除
+func (s *CommentService) Delete(ctx context.Context, actor Actor, id uint) error {
+	comment, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID != actor.ID && !actor.Can(models.PermCommentModerate) {
+		post, err := s.posts.FindByID(ctx, comment.PostID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if post == nil || post.UserID != actor.ID {
+			return ErrForbidden
+		}
+	}
+	if err := s.comments.Delete(ctx, comment); err != nil {
+		return err
+	}
+	s.indexer.RemoveComment(comment.ID)
+	return nil
+}
+
+// find 查找评论，不存在时返回 ErrCommentNotFound
+func (s *CommentService) find(ctx context.Context, id uint) (*models.Comment, error) {
+	comment, err := s.comments.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCommentNotFound
+	}
+	return comment, err
+}
+
+// ListThreads 按创建时间倒序分页返回文章的顶级评论，每条顶级评论带有完整的回复树，
+// 回复按创建时间正序排列。文章不存在时返回 ErrPostNotFound。
+func (s *CommentService) ListThreads(ctx context.Context, postID uint, cursor string, limit int) (*CommentPage, error) {
+	if err := s.ensurePost(ctx, postID); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultCommentLimit
+	}
+	if limit > MaxCommentLimit {
+		limit = MaxCommentLimit
+	}
+
+	var after *repository.CommentCursor
+	if cursor != "" {
+		var c commentCursor
+		if err := decodeCursor(cursor, &c); err != nil {
+			return nil, err
+		}
+		if c.ID == 0 {
+			return nil, ErrInvalidCursor
+		}
+		after = &repository.CommentCursor{CreatedAt: c.CreatedAt, ID: c.ID}
+	}
+
+	total, err := s.comments.CountTopLevel(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 多取一条用于判断是否还有下一页
+	top, err := s.comments.ListTopLevel(ctx, postID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &CommentPage{Total: total, Limit: limit}
+	if len(top) > limit {
+		top = top[:limit]
+		last := top[len(top)-1]
+		page.NextCursor = encodeCursor(commentCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	page.Comments, err = s.buildTrees(ctx, postID, top)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// buildTrees 逐层加载顶级评论的回复并组装成树
+func (s *CommentService) buildTrees(ctx context.Context, postID uint, top []models.Comment) ([]*CommentNode, error) {
+	roots := make([]*CommentNode, 0, len(top))
+	level := make(map[uint]*CommentNode, len(top))
+	for _, c := range top {
+		node := newCommentNode(c, 0)
+		roots = append(roots, node)
+		level[c.ID] = node
+	}
+
+	for depth := 1; len(level) > 0; depth++ {
+		ids := make([]uint, 0, len(level))
+		for id := range level {
+			ids = append(ids, id)
+		}
+
+		replies, err := s.comments.ListReplies(ctx, postID, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		next := make(map[uint]*CommentNode, len(replies))
+		for _, r := range replies {
+			node := newCommentNode(r, depth)
+			parent := level[*r.ParentID]
+			parent.Replies = append(parent.Replies, node)
+			next[r.ID] = node
+		}
+		level = next
+	}
+	return roots, nil
+}
+
+// FlattenThreads 把评论树按先序展开为扁平列表，用 Depth 表示层级
+func FlattenThreads(roots []*CommentNode) []*CommentNode {
+	var flat []*CommentNode
+	var walk func(nodes []*CommentNode)
+	walk = func(nodes []*CommentNode) {
+		for _, n := range nodes {
+			flat = append(flat, &CommentNode{Comment: n.Comment, Deleted: n.Deleted, Depth: n.Depth})
+			walk(n.Replies)
+		}
+	}
+	walk(roots)
+	return flat
+}
+
+// ensurePost 检查文章是否存在且已发布，未发布的文章不能查看和发表评论
+func (s *CommentService) ensurePost(ctx context.Context, postID uint) error {
+	_, err := findPublishedPost(ctx, s.posts, postID)
+	return err
+}
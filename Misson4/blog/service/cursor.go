@@ -0,0 +1,24 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// encodeCursor 把游标序列化为不透明的 URL 安全字符串
+func encodeCursor(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor 解析 encodeCursor 生成的字符串，格式不对时返回 ErrInvalidCursor
+func decodeCursor(s string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"blog/repository"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC)
+	tests := []struct {
+		name string
+		in   interface{}
+		out  interface{}
+	}{
+		{"comment", &commentCursor{CreatedAt: at, ID: 42}, &commentCursor{}},
+		{"post by time", &postCursor{Sort: repository.SortCreatedAt, Time: at, ID: 7}, &postCursor{}},
+		{"post by count ascending", &postCursor{Sort: repository.SortCommentCount, Asc: true, Count: 3, ID: 9}, &postCursor{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := encodeCursor(tt.in)
+			if err := decodeCursor(s, tt.out); err != nil {
+				t.Fatal(err)
+			}
+			switch in := tt.in.(type) {
+			case *commentCursor:
+				out := tt.out.(*commentCursor)
+				if !out.CreatedAt.Equal(in.CreatedAt) || out.ID != in.ID {
+					t.Errorf("decoded %+v, want %+v", out, in)
+				}
+			case *postCursor:
+				out := tt.out.(*postCursor)
+				if out.Sort != in.Sort || out.Asc != in.Asc || !out.Time.Equal(in.Time) || out.Count != in.Count || out.ID != in.ID {
+					t.Errorf("decoded %+v, want %+v", out, in)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"empty", ""},
+		{"not base64", "!!not-base64!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"id":1}`))},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("cursor"))},
+		{"wrong field type", base64.RawURLEncoding.EncodeToString([]byte(`{"id":"x"}`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c commentCursor
+			if err := decodeCursor(tt.cursor, &c); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor(%q) = %v, want ErrInvalidCursor", tt.cursor, err)
+			}
+		})
+	}
+}
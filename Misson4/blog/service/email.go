@@ -0,0 +1,201 @@
+package service
+
+import (
+	"blog/mail"
+	"blog/models"
+	"blog/repository"
+	"blog/signedtoken"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 邮件令牌的用途，防止验证邮箱的令牌被用来重置密码
+const (
+	purposeVerifyEmail   = "verify-email"
+	purposeResetPassword = "reset-password"
+)
+
+// EmailOptions 邮件链接的地址和有效期
+type EmailOptions struct {
+	// BaseURL 前端页面地址，链接为 BaseURL/verify-email?token=... 和 BaseURL/reset-password?token=...
+	BaseURL      string
+	VerifyExpiry time.Duration
+	ResetExpiry  time.Duration
+}
+
+// EmailService 邮箱验证和找回密码。邮件中的令牌不保存在数据库中：
+// 验证令牌绑定签发时的邮箱，重置令牌绑定签发时的密码哈希，因此密码修改后重置链接随即失效。
+type EmailService struct {
+	users  repository.UserRepository
+	tokens *TokenService
+	mailer mail.Mailer
+	signer *signedtoken.Signer
+	opts   EmailOptions
+}
+
+// NewEmailService 创建 EmailService，重置密码后通过 tokens 作废用户所有的刷新令牌
+func NewEmailService(users repository.UserRepository, tokens *TokenService, mailer mail.Mailer, signer *signedtoken.Signer, opts EmailOptions) *EmailService {
+	return &EmailService{users: users, tokens: tokens, mailer: mailer, signer: signer, opts: opts}
+}
+
+// SendVerification 向用户的邮箱发送验证链接
+func (s *EmailService) SendVerification(ctx context.Context, user *models.User) error {
+	token, err := s.signer.Sign(signedtoken.Claims{
+		Purpose:   purposeVerifyEmail,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(s.opts.VerifyExpiry).Unix(),
+		State:     signedtoken.Fingerprint(user.Email),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "请验证你的邮箱",
+		Body: fmt.Sprintf("%s，你好：\n\n请打开以下链接验证你的邮箱，链接在 %s内有效：\n\n%s\n\n如果你没有注册过账号，请忽略这封邮件。\n",
+			user.Username, formatExpiry(s.opts.VerifyExpiry), s.link("verify-email", token)),
+	})
+}
+
+// ResendVerification 重新发送验证邮件，邮箱已验证时返回 ErrEmailVerified
+func (s *EmailService) ResendVerification(ctx context.Context, userID uint) error {
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailVerified
+	}
+	return s.SendVerification(ctx, user)
+}
+
+// VerifyEmail 校验验证令牌并记录验证时间，已验证过时直接返回用户。
+// 令牌无效、过期或邮箱已变化时返回 ErrInvalidEmailToken。
+func (s *EmailService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	claims, err := s.signer.Verify(token, purposeVerifyEmail, time.Now())
+	if err != nil {
+		return nil, ErrInvalidEmailToken
+	}
+	user, err := s.findTokenUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if claims.State != signedtoken.Fingerprint(user.Email) {
+		return nil, ErrInvalidEmailToken
+	}
+	if user.EmailVerifiedAt != nil {
+		return user, nil
+	}
+
+	now := time.Now()
+	ok, err := s.users.MarkEmailVerified(ctx, user.ID, user.Email, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidEmailToken
+	}
+	user.EmailVerifiedAt = &now
+	return user, nil
+}
+
+// RequestPasswordReset 向 email 对应的用户发送重置密码链接。
+// 邮箱不存在时也返回 nil，避免通过该接口探测邮箱是否已注册。
+func (s *EmailService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.users.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := s.signer.Sign(signedtoken.Claims{
+		Purpose:   purposeResetPassword,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(s.opts.ResetExpiry).Unix(),
+		State:     signedtoken.Fingerprint(user.Password),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "重置密码",
+		Body: fmt.Sprintf("%s，你好：\n\n我们收到了重置密码的请求，请打开以下链接设置新密码，链接在 %s内有效且只能使用一次：\n\n%s\n\n如果不是你本人操作，请忽略这封邮件，你的密码不会改变。\n",
+			user.Username, formatExpiry(s.opts.ResetExpiry), s.link("reset-password", token)),
+	})
+}
+
+// ResetPassword 校验重置令牌后设置新密码，并作废用户所有的刷新令牌，返回密码被重置的用户。
+// 令牌无效、过期或已经使用过（密码已变化）时返回 ErrInvalidEmailToken；
+// 同一令牌并发使用时只有一次能成功，其余同样返回 ErrInvalidEmailToken。
+func (s *EmailService) ResetPassword(ctx context.Context, token, newPassword string) (*models.User, error) {
+	claims, err := s.signer.Verify(token, purposeResetPassword, time.Now())
+	if err != nil {
+		return nil, ErrInvalidEmailToken
+	}
+	user, err := s.findTokenUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if claims.State != signedtoken.Fingerprint(user.Password) {
+		return nil, ErrInvalidEmailToken
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("hash password: %w", err)
+	}
+	// 只在密码仍为签发令牌时的密码时更新，校验和更新之间密码被改过说明令牌已被使用
+	updated, err := s.users.UpdatePassword(ctx, user.ID, user.Password, string(hash))
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrInvalidEmailToken
+	}
+	// 能收到重置邮件说明邮箱属于该用户
+	if user.EmailVerifiedAt == nil {
+		if _, err := s.users.MarkEmailVerified(ctx, user.ID, user.Email, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.tokens.LogoutAll(ctx, user.ID, "", time.Time{}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// findTokenUser 查找令牌对应的用户，用户不存在（例如已注销）时返回 ErrInvalidEmailToken
+func (s *EmailService) findTokenUser(ctx context.Context, claims *signedtoken.Claims) (*models.User, error) {
+	user, err := s.users.FindByID(ctx, claims.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidEmailToken
+	}
+	return user, err
+}
+
+// link 生成邮件中指向前端页面的链接
+func (s *EmailService) link(page, token string) string {
+	return strings.TrimRight(s.opts.BaseURL, "/") + "/" + page + "?token=" + url.QueryEscape(token)
+}
+
+// formatExpiry 把有效期格式化为 "48 小时"、"30 分钟" 这样的文字
+func formatExpiry(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return fmt.Sprintf("%d 小时", d/time.Hour)
+	}
+	return fmt.Sprintf("%d 分钟", (d+time.Minute-1)/time.Minute)
+}
@@ -0,0 +1,55 @@
+// Package service 实现博客的业务规则（存在性检查、作者权限等），
+// 通过 repository 接口访问数据，handlers 只负责 HTTP 的输入输出。
+package service
+
+import "errors"
+
+// 业务错误，handlers 根据这些错误决定响应状态码
+var (
+	ErrPostNotFound       = errors.New("post not found")
+	ErrCommentNotFound    = errors.New("comment not found")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrForbidden          = errors.New("forbidden")
+	ErrUsernameTaken      = errors.New("username already exists")
+	ErrEmailTaken         = errors.New("email already exists")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrInvalidParent      = errors.New("parent comment does not belong to this post")
+	ErrInvalidToken       = errors.New("invalid or expired refresh token")
+	ErrTokenReused        = errors.New("refresh token reused")
+	ErrInvalidRole        = errors.New("invalid role")
+	ErrOwnRole            = errors.New("cannot change your own role")
+	ErrInvalidQuery       = errors.New("invalid search query")
+	ErrInvalidTag         = errors.New("invalid tags")
+	ErrTagNotFound        = errors.New("tag not found")
+	ErrCategoryNotFound   = errors.New("category not found")
+	ErrCategoryExists     = errors.New("category already exists")
+	ErrInvalidStatus      = errors.New("invalid post status")
+	ErrInvalidSchedule    = errors.New("scheduled posts need a future publish time")
+	ErrRevisionNotFound   = errors.New("revision not found")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrFileTooLarge       = errors.New("file too large")
+	ErrUnsupportedFile    = errors.New("unsupported file type")
+	ErrInvalidProfile     = errors.New("invalid profile")
+	ErrWrongPassword      = errors.New("current password is incorrect")
+	ErrAdminAccount       = errors.New("admins cannot delete their own account")
+	ErrEmailVerified      = errors.New("email already verified")
+	ErrInvalidEmailToken  = errors.New("invalid or expired link")
+)
+
+// FieldError 某个输入字段不合法，Err 为对应的业务错误（如 ErrInvalidProfile），
+// handlers 据此返回字段级别的校验错误
+type FieldError struct {
+	Err     error
+	Field   string
+	Rule    string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error() + ": " + e.Message
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,567 @@
+package service
+
+import (
+	"blog/markdown"
+	"blog/models"
+	"blog/repository"
+	"blog/search"
+	"blog/slug"
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// 文章列表分页默认值
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+)
+
+// CreatePostInput 创建文章参数。Status 为空时直接发布；
+// 定时发布时 PublishAt 必须晚于当前时间，其他状态忽略 PublishAt。
+type CreatePostInput struct {
+	Title      string
+	Content    string
+	CategoryID *uint
+	Tags       []string
+	Status     models.PostStatus
+	PublishAt  *time.Time
+}
+
+// UpdatePostInput 更新文章参数，空字段表示不修改。
+// CategoryID 指向 0 表示取消分类；Tags 指向空切片表示清空标签；
+// 只传 PublishAt 表示修改定时文章的发布时间。
+type UpdatePostInput struct {
+	Title      string
+	Content    string
+	CategoryID *uint
+	Tags       *[]string
+	Status     models.PostStatus
+	PublishAt  *time.Time
+}
+
+// ListPostsInput 文章列表参数。Cursor 不为空时使用游标分页并忽略 Page。
+// Status 只在查看自己的文章时生效。
+type ListPostsInput struct {
+	Page       int
+	PageSize   int
+	Cursor     string
+	Sort       repository.PostSort
+	Asc        bool
+	AuthorID   uint
+	Status     models.PostStatus
+	Tag        string
+	CategoryID uint
+	// BookmarkedBy 只返回该用户收藏的文章，由 ListBookmarks 设置
+	BookmarkedBy uint
+	From         *time.Time
+	To           *time.Time
+	// Viewer 当前登录用户，不为 0 时为每篇文章填充 Liked 和 Bookmarked
+	Viewer uint
+}
+
+// PostPage 一页文章及分页信息
+type PostPage struct {
+	Posts      []models.Post
+	Total      int64
+	Page       int // 游标分页时为 0
+	PageSize   int
+	NextCursor string // 没有下一页时为空
+}
+
+// postCursor 游标的序列化形式，带上排序方式以拒绝跨排序复用的游标
+type postCursor struct {
+	Sort  repository.PostSort `json:"s"`
+	Asc   bool                `json:"a,omitempty"`
+	Time  time.Time           `json:"t,omitempty"`
+	Count int64               `json:"c,omitempty"`
+	ID    uint                `json:"id"`
+}
+
+// PostService 文章业务逻辑
+type PostService struct {
+	posts      repository.PostRepository
+	tags       repository.TagRepository
+	categories repository.CategoryRepository
+	reactions  repository.ReactionRepository
+	indexer    search.Indexer
+	views      *ViewCounter
+}
+
+// NewPostService 创建 PostService，文章变化时同步更新 indexer，查看文章详情时由 views 记录浏览量，
+// reactions 用于标记当前用户是否已点赞、收藏
+func NewPostService(posts repository.PostRepository, tags repository.TagRepository, categories repository.CategoryRepository, reactions repository.ReactionRepository, indexer search.Indexer, views *ViewCounter) *PostService {
+	return &PostService{posts: posts, tags: tags, categories: categories, reactions: reactions, indexer: indexer, views: views}
+}
+
+// Create 以 userID 作为作者创建文章，返回加载了作者、分类和标签的文章
+func (s *PostService) Create(ctx context.Context, userID uint, in CreatePostInput) (*models.Post, error) {
+	if err := s.checkCategory(ctx, in.CategoryID); err != nil {
+		return nil, err
+	}
+	tags, err := s.resolveTags(ctx, in.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	post := &models.Post{
+		Title:      in.Title,
+		Content:    in.Content,
+		UserID:     userID,
+		CategoryID: in.CategoryID,
+		Tags:       tags,
+	}
+	renderPost(post)
+	if post.Slug, err = s.uniqueSlug(ctx, in.Title, 0); err != nil {
+		return nil, err
+	}
+	if in.Status == "" {
+		in.Status = models.PostPublished
+	}
+	if err := applyStatus(post, in.Status, in.PublishAt, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.posts.Create(ctx, post); err != nil {
+		return nil, err
+	}
+	s.indexer.IndexPost(post)
+	return s.posts.FindByID(ctx, post.ID)
+}
+
+// List 按条件分页返回已发布的文章，游标与当前排序方式不匹配时返回 ErrInvalidCursor
+func (s *PostService) List(ctx context.Context, in ListPostsInput) (*PostPage, error) {
+	in.Status = models.PostPublished
+	return s.list(ctx, in)
+}
+
+// ListOwn 分页返回 userID 自己的文章，包括草稿、定时和已归档的文章，可以按 Status 过滤
+func (s *PostService) ListOwn(ctx context.Context, userID uint, in ListPostsInput) (*PostPage, error) {
+	in.AuthorID = userID
+	in.Viewer = userID
+	return s.list(ctx, in)
+}
+
+// ListBookmarks 分页返回 userID 收藏的已发布文章
+func (s *PostService) ListBookmarks(ctx context.Context, userID uint, in ListPostsInput) (*PostPage, error) {
+	in.Status = models.PostPublished
+	in.BookmarkedBy = userID
+	in.Viewer = userID
+	return s.list(ctx, in)
+}
+
+// list 按条件分页返回文章
+func (s *PostService) list(ctx context.Context, in ListPostsInput) (*PostPage, error) {
+	if in.PageSize <= 0 {
+		in.PageSize = DefaultPageSize
+	}
+	if in.PageSize > MaxPageSize {
+		in.PageSize = MaxPageSize
+	}
+	if in.Page <= 0 {
+		in.Page = 1
+	}
+	if in.Sort == "" {
+		in.Sort = repository.SortCreatedAt
+	}
+
+	q := repository.PostQuery{
+		AuthorID:     in.AuthorID,
+		Status:       in.Status,
+		Tag:          strings.ToLower(strings.TrimSpace(in.Tag)),
+		CategoryID:   in.CategoryID,
+		BookmarkedBy: in.BookmarkedBy,
+		CreatedFrom:  in.From,
+		CreatedTo:    in.To,
+		Sort:         in.Sort,
+		Desc:         !in.Asc,
+		// 多取一条用于判断是否还有下一页
+		Limit: in.PageSize + 1,
+	}
+	if in.Cursor != "" {
+		var c postCursor
+		if err := decodeCursor(in.Cursor, &c); err != nil {
+			return nil, err
+		}
+		if c.ID == 0 || c.Sort != in.Sort || c.Asc != in.Asc {
+			return nil, ErrInvalidCursor
+		}
+		q.After = &repository.PostCursor{Time: c.Time, Count: c.Count, ID: c.ID}
+	} else {
+		q.Offset = (in.Page - 1) * in.PageSize
+	}
+
+	posts, total, err := s.posts.List(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &PostPage{Total: total, Page: in.Page, PageSize: in.PageSize}
+	if q.After != nil {
+		// 游标分页没有页码的概念
+		page.Page = 0
+	}
+	if len(posts) > in.PageSize {
+		posts = posts[:in.PageSize]
+		last := posts[len(posts)-1]
+		c := postCursor{Sort: in.Sort, Asc: in.Asc, ID: last.ID}
+		switch in.Sort {
+		case repository.SortUpdatedAt:
+			c.Time = last.UpdatedAt
+		case repository.SortCommentCount:
+			c.Count = last.CommentCount
+		default:
+			c.Time = last.CreatedAt
+		}
+		page.NextCursor = encodeCursor(c)
+	}
+	ptrs := make([]*models.Post, len(posts))
+	for i := range posts {
+		posts[i].ViewCount += s.views.Pending(posts[i].ID)
+		ptrs[i] = &posts[i]
+	}
+	if err := s.markReactions(ctx, in.Viewer, ptrs...); err != nil {
+		return nil, err
+	}
+	page.Posts = posts
+	return page, nil
+}
+
+// Get 返回文章详情，不含评论，评论通过 CommentService.List 分页获取。已发布的文章所有人可见并记录一次浏览；
+// 未发布的文章只有作者和拥有 post:moderate 权限的 viewer 可见，其他人视为不存在。
+// viewer 未登录时 ID 为 0
+func (s *PostService) Get(ctx context.Context, viewer Actor, id uint) (*models.Post, error) {
+	post, err := visiblePost(viewer)(s.posts.FindByID(ctx, id))
+	if err != nil {
+		return nil, err
+	}
+	if post.Status == models.PostPublished {
+		s.recordView(post)
+	}
+	if err := s.markReactions(ctx, viewer.ID, post); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// GetBySlug 按 slug 返回文章详情，可见性与 Get 相同。slug 可以是文章改名前用过的旧 slug，
+// 调用方通过比较返回文章的 Slug 判断是否需要重定向；使用旧 slug 时不记录浏览
+func (s *PostService) GetBySlug(ctx context.Context, viewer Actor, slug string) (*models.Post, error) {
+	post, err := visiblePost(viewer)(s.posts.FindBySlug(ctx, slug))
+	if err != nil {
+		return nil, err
+	}
+	if post.Slug == slug && post.Status == models.PostPublished {
+		s.recordView(post)
+	}
+	if err := s.markReactions(ctx, viewer.ID, post); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// recordView 记录一次浏览，返回的浏览量包括尚未写入数据库的部分
+func (s *PostService) recordView(post *models.Post) {
+	s.views.Hit(post.ID)
+	post.ViewCount += s.views.Pending(post.ID)
+}
+
+// markReactions 为 userID 填充每篇文章的 Liked 和 Bookmarked，userID 为 0 时不填充
+func (s *PostService) markReactions(ctx context.Context, userID uint, posts ...*models.Post) error {
+	if userID == 0 || len(posts) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	liked, bookmarked, err := s.reactions.Reactions(ctx, userID, ids)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		l, b := liked[post.ID], bookmarked[post.ID]
+		post.Liked, post.Bookmarked = &l, &b
+	}
+	return nil
+}
+
+// visiblePost 返回过滤函数，过滤掉不存在的文章和 viewer 无权查看的未发布文章
+func visiblePost(viewer Actor) func(*models.Post, error) (*models.Post, error) {
+	return func(post *models.Post, err error) (*models.Post, error) {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrPostNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if post.Status != models.PostPublished && !canManagePost(viewer, post) {
+			return nil, ErrPostNotFound
+		}
+		return post, nil
+	}
+}
+
+// Update 更新文章，作者和拥有 post:moderate 权限的用户可以修改。
+// 标题或内容有变化时记录一个新版本。
+func (s *PostService) Update(ctx context.Context, actor Actor, id uint, in UpdatePostInput) (*models.Post, error) {
+	post, err := s.findManaged(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 标题或内容有变化时才记录新版本
+	changed := (in.Title != "" && in.Title != post.Title) || (in.Content != "" && in.Content != post.Content)
+	if in.Title != "" {
+		if err := s.setTitle(ctx, post, in.Title); err != nil {
+			return nil, err
+		}
+	}
+	if in.Content != "" {
+		post.Content = in.Content
+		renderPost(post)
+	}
+	if in.CategoryID != nil {
+		if *in.CategoryID == 0 {
+			post.CategoryID = nil
+		} else if err := s.checkCategory(ctx, in.CategoryID); err != nil {
+			return nil, err
+		} else {
+			post.CategoryID = in.CategoryID
+		}
+	}
+	status := in.Status
+	if status == "" && in.PublishAt != nil {
+		status = post.Status
+	}
+	if status != "" {
+		if err := applyStatus(post, status, in.PublishAt, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	var tags []models.Tag
+	if in.Tags != nil {
+		if tags, err = s.resolveTags(ctx, *in.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	// 文章、新版本和标签在同一事务中保存
+	var revision *models.PostRevision
+	if changed {
+		revision = &models.PostRevision{EditorID: actor.ID}
+	}
+	switch {
+	case in.Tags != nil:
+		err = s.posts.UpdateWithTags(ctx, post, revision, tags)
+	case changed:
+		err = s.posts.UpdateWithRevision(ctx, post, revision)
+	default:
+		err = s.posts.Update(ctx, post)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.indexer.IndexPost(post)
+	return s.posts.FindByID(ctx, post.ID)
+}
+
+// AttachTags 为文章追加标签，权限同 Update。追加后超过 MaxTagsPerPost 个时返回 ErrInvalidTag。
+func (s *PostService) AttachTags(ctx context.Context, actor Actor, id uint, names []string) (*models.Post, error) {
+	post, err := s.findManaged(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err = normalizeTags(names)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(post.Tags))
+	for _, tag := range post.Tags {
+		existing[tag.Name] = true
+	}
+	count := len(post.Tags)
+	for _, name := range names {
+		if !existing[name] {
+			count++
+		}
+	}
+	if len(names) == 0 || count > MaxTagsPerPost {
+		return nil, ErrInvalidTag
+	}
+
+	tags, err := s.tags.FindOrCreate(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.posts.AppendTags(ctx, post, tags); err != nil {
+		return nil, err
+	}
+	return s.posts.FindByID(ctx, post.ID)
+}
+
+// DetachTag 解除文章与标签的关联，权限同 Update。文章没有该标签时返回 ErrTagNotFound。
+func (s *PostService) DetachTag(ctx context.Context, actor Actor, id uint, name string) (*models.Post, error) {
+	post, err := s.findManaged(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i := range post.Tags {
+		if post.Tags[i].Name != name {
+			continue
+		}
+		if err := s.posts.RemoveTag(ctx, post, &post.Tags[i]); err != nil {
+			return nil, err
+		}
+		return s.posts.FindByID(ctx, post.ID)
+	}
+	return nil, ErrTagNotFound
+}
+
+// Delete 删除文章（级联删除评论），作者和拥有 post:moderate 权限的用户可以删除
+func (s *PostService) Delete(ctx context.Context, actor Actor, id uint) error {
+	post, err := s.findManaged(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	if err := s.posts.Delete(ctx, post); err != nil {
+		return err
+	}
+	s.indexer.RemovePost(post.ID)
+	return nil
+}
+
+// PublishDue 发布所有到期的定时文章，返回发布的数量
+func (s *PostService) PublishDue(ctx context.Context) (int, error) {
+	posts, err := s.posts.PublishDue(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for i := range posts {
+		s.indexer.IndexPost(&posts[i])
+	}
+	return len(posts), nil
+}
+
+// applyStatus 把文章切换到 status 并设置对应的发布时间。
+// 未知状态返回 ErrInvalidStatus，定时发布要求 publishAt 晚于 now，否则返回 ErrInvalidSchedule；
+// 重新发布已归档的文章时保留原来的发布时间。
+func applyStatus(post *models.Post, status models.PostStatus, publishAt *time.Time, now time.Time) error {
+	switch status {
+	case models.PostDraft:
+		post.PublishAt = nil
+	case models.PostScheduled:
+		if publishAt == nil || !publishAt.After(now) {
+			return ErrInvalidSchedule
+		}
+		post.PublishAt = publishAt
+	case models.PostPublished:
+		if post.PublishAt == nil || post.PublishAt.After(now) {
+			post.PublishAt = &now
+		}
+	case models.PostArchived:
+	default:
+		return ErrInvalidStatus
+	}
+	post.Status = status
+	return nil
+}
+
+// setTitle 修改文章标题，标题有变化时重新生成 slug
+func (s *PostService) setTitle(ctx context.Context, post *models.Post, title string) error {
+	if title == post.Title {
+		return nil
+	}
+	newSlug, err := s.uniqueSlug(ctx, title, post.ID)
+	if err != nil {
+		return err
+	}
+	post.Title = title
+	post.Slug = newSlug
+	return nil
+}
+
+// uniqueSlug 根据标题生成未被其他文章用过的 slug。
+// postID 为正在修改的文章，它自己用过的旧 slug 可以重新使用；新建文章时为 0。
+func (s *PostService) uniqueSlug(ctx context.Context, title string, postID uint) (string, error) {
+	return slug.Unique(slug.Make(title), func(candidate string) (bool, error) {
+		return s.posts.SlugTaken(ctx, candidate, postID)
+	})
+}
+
+// renderPost 根据 Content 生成 ContentHTML 和 Excerpt
+func renderPost(post *models.Post) {
+	post.ContentHTML = markdown.Render(post.Content)
+	post.Excerpt = markdown.Excerpt(post.ContentHTML, markdown.DefaultExcerptLength)
+}
+
+// checkCategory 检查分类是否存在，id 为空表示不设置分类
+func (s *PostService) checkCategory(ctx context.Context, id *uint) error {
+	if id == nil {
+		return nil
+	}
+	_, err := s.categories.FindByID(ctx, *id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrCategoryNotFound
+	}
+	return err
+}
+
+// resolveTags 规范化标签名并查找或创建对应的标签
+func (s *PostService) resolveTags(ctx context.Context, names []string) ([]models.Tag, error) {
+	names, err := normalizeTags(names)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) > MaxTagsPerPost {
+		return nil, ErrInvalidTag
+	}
+	return s.tags.FindOrCreate(ctx, names)
+}
+
+// findManaged 查找文章并检查 actor 是否为作者或拥有 post:moderate 权限
+func (s *PostService) findManaged(ctx context.Context, actor Actor, id uint) (*models.Post, error) {
+	return findManagedPost(ctx, s.posts, actor, id)
+}
+
+// findPublishedPost 查找已发布的文章，不存在或未发布时返回 ErrPostNotFound
+func findPublishedPost(ctx context.Context, posts repository.PostRepository, id uint) (*models.Post, error) {
+	post, err := posts.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrPostNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if post.Status != models.PostPublished {
+		return nil, ErrPostNotFound
+	}
+	return post, nil
+}
+
+// findManagedPost 查找文章并检查 actor 是否为作者或拥有 post:moderate 权限
+func findManagedPost(ctx context.Context, posts repository.PostRepository, actor Actor, id uint) (*models.Post, error) {
+	post, err := posts.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrPostNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !canManagePost(actor, post) {
+		return nil, ErrForbidden
+	}
+	return post, nil
+}
+
+// canManagePost actor 是否为作者或拥有 post:moderate 权限，未登录的 actor 没有任何权限
+func canManagePost(actor Actor, post *models.Post) bool {
+	if actor.ID == 0 {
+		return false
+	}
+	return post.UserID == actor.ID || actor.Can(models.PermPostModerate)
+}
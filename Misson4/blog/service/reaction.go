@@ -0,0 +1,66 @@
+package service
+
+import (
+	"blog/repository"
+	"context"
+)
+
+// ReactionService 点赞和收藏，只能对已发布的文章操作。所有操作都是幂等的
+type ReactionService struct {
+	reactions repository.ReactionRepository
+	posts     repository.PostRepository
+}
+
+// NewReactionService 创建 ReactionService
+func NewReactionService(reactions repository.ReactionRepository, posts repository.PostRepository) *ReactionService {
+	return &ReactionService{reactions: reactions, posts: posts}
+}
+
+// Like 点赞文章，返回最新的点赞数
+func (s *ReactionService) Like(ctx context.Context, userID, postID uint) (int64, error) {
+	if _, err := findPublishedPost(ctx, s.posts, postID); err != nil {
+		return 0, err
+	}
+	if _, err := s.reactions.Like(ctx, userID, postID); err != nil {
+		return 0, err
+	}
+	return s.likeCount(ctx, postID)
+}
+
+// Unlike 取消点赞，返回最新的点赞数
+func (s *ReactionService) Unlike(ctx context.Context, userID, postID uint) (int64, error) {
+	if _, err := findPublishedPost(ctx, s.posts, postID); err != nil {
+		return 0, err
+	}
+	if _, err := s.reactions.Unlike(ctx, userID, postID); err != nil {
+		return 0, err
+	}
+	return s.likeCount(ctx, postID)
+}
+
+// Bookmark 收藏文章
+func (s *ReactionService) Bookmark(ctx context.Context, userID, postID uint) error {
+	if _, err := findPublishedPost(ctx, s.posts, postID); err != nil {
+		return err
+	}
+	_, err := s.reactions.Bookmark(ctx, userID, postID)
+	return err
+}
+
+// Unbookmark 取消收藏
+func (s *ReactionService) Unbookmark(ctx context.Context, userID, postID uint) error {
+	if _, err := findPublishedPost(ctx, s.posts, postID); err != nil {
+		return err
+	}
+	_, err := s.reactions.Unbookmark(ctx, userID, postID)
+	return err
+}
+
+// likeCount 重新读取文章的点赞数，包含其他用户并发的点赞
+func (s *ReactionService) likeCount(ctx context.Context, postID uint) (int64, error) {
+	post, err := findPublishedPost(ctx, s.posts, postID)
+	if err != nil {
+		return 0, err
+	}
+	return post.LikeCount, nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"blog/diff"
+	"blog/models"
+	"blog/repository"
+	"context"
+	"errors"
+)
+
+// RevisionDiff 两个版本之间的差异，标题整体比较，内容按行比较
+type RevisionDiff struct {
+	PostID   uint        `json:"post_id"`
+	From     int         `json:"from"`
+	To       int         `json:"to"`
+	OldTitle string      `json:"old_title"`
+	NewTitle string      `json:"new_title"`
+	Lines    []diff.Line `json:"lines"`
+	Added    int         `json:"added"`
+	Removed  int         `json:"removed"`
+}
+
+// Revisions 返回文章的版本列表（不含内容），作者和拥有 post:moderate 权限的用户可以查看
+func (s *PostService) Revisions(ctx context.Context, actor Actor, postID uint) ([]models.PostRevision, error) {
+	if _, err := s.findManaged(ctx, actor, postID); err != nil {
+		return nil, err
+	}
+	return s.posts.ListRevisions(ctx, postID)
+}
+
+// Revision 返回文章的某个版本，权限同 Revisions
+func (s *PostService) Revision(ctx context.Context, actor Actor, postID uint, version int) (*models.PostRevision, error) {
+	if _, err := s.findManaged(ctx, actor, postID); err != nil {
+		return nil, err
+	}
+	return s.findRevision(ctx, postID, version)
+}
+
+// DiffRevisions 比较文章的两个版本，to 为 0 时与最新版本比较，权限同 Revisions
+func (s *PostService) DiffRevisions(ctx context.Context, actor Actor, postID uint, from, to int) (*RevisionDiff, error) {
+	if _, err := s.findManaged(ctx, actor, postID); err != nil {
+		return nil, err
+	}
+
+	if to == 0 {
+		revisions, err := s.posts.ListRevisions(ctx, postID)
+		if err != nil {
+			return nil, err
+		}
+		if len(revisions) == 0 {
+			return nil, ErrRevisionNotFound
+		}
+		to = revisions[0].Version
+	}
+
+	old, err := s.findRevision(ctx, postID, from)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := s.findRevision(ctx, postID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := diff.Lines(old.Content, cur.Content)
+	added, removed := diff.Stats(lines)
+	return &RevisionDiff{
+		PostID:   postID,
+		From:     from,
+		To:       to,
+		OldTitle: old.Title,
+		NewTitle: cur.Title,
+		Lines:    lines,
+		Added:    added,
+		Removed:  removed,
+	}, nil
+}
+
+// RestoreRevision 把文章的标题和内容恢复为某个版本，并记录为一个新版本。
+// 权限同 Update；内容与当前一致时不做修改。
+func (s *PostService) RestoreRevision(ctx context.Context, actor Actor, postID uint, version int) (*models.Post, error) {
+	post, err := s.findManaged(ctx, actor, postID)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := s.findRevision(ctx, postID, version)
+	if err != nil {
+		return nil, err
+	}
+	if revision.Title == post.Title && revision.Content == post.Content {
+		return post, nil
+	}
+
+	if err := s.setTitle(ctx, post, revision.Title); err != nil {
+		return nil, err
+	}
+	post.Content = revision.Content
+	renderPost(post)
+	err = s.posts.UpdateWithRevision(ctx, post, &models.PostRevision{
+		EditorID:     actor.ID,
+		RestoredFrom: &revision.Version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.indexer.IndexPost(post)
+	return s.posts.FindByID(ctx, post.ID)
+}
+
+// findRevision 查找版本，不存在时返回 ErrRevisionNotFound
+func (s *PostService) findRevision(ctx context.Context, postID uint, version int) (*models.PostRevision, error) {
+	revision, err := s.posts.FindRevision(ctx, postID, version)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrRevisionNotFound
+	}
+	return revision, err
+}
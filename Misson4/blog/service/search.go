@@ -0,0 +1,38 @@
+package service
+
+import (
+	"blog/search"
+	"context"
+	"unicode/utf8"
+)
+
+// 搜索参数限制
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 50
+	MaxQueryLength     = 100
+)
+
+// SearchService 全文搜索
+type SearchService struct {
+	searcher search.Searcher
+}
+
+// NewSearchService 创建 SearchService
+func NewSearchService(searcher search.Searcher) *SearchService {
+	return &SearchService{searcher: searcher}
+}
+
+// Search 搜索文章和评论，kind 为空表示都搜索
+func (s *SearchService) Search(ctx context.Context, text, kind string, limit int) ([]search.Result, error) {
+	if text == "" || utf8.RuneCountInString(text) > MaxQueryLength {
+		return nil, ErrInvalidQuery
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+	return s.searcher.Search(ctx, search.Query{Text: text, Type: kind, Limit: limit})
+}
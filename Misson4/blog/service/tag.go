@@ -0,0 +1,76 @@
+package service
+
+import (
+	"blog/models"
+	"blog/repository"
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// 标签限制
+const (
+	MaxTagsPerPost = 10
+	MaxTagLength   = 50
+)
+
+// CreateCategoryInput 创建分类参数
+type CreateCategoryInput struct {
+	Name        string
+	Description string
+}
+
+// TagService 标签和分类
+type TagService struct {
+	tags       repository.TagRepository
+	categories repository.CategoryRepository
+}
+
+// NewTagService 创建 TagService
+func NewTagService(tags repository.TagRepository, categories repository.CategoryRepository) *TagService {
+	return &TagService{tags: tags, categories: categories}
+}
+
+// ListTags 返回有文章的标签及文章数
+func (s *TagService) ListTags(ctx context.Context) ([]repository.TagCount, error) {
+	return s.tags.ListWithCounts(ctx)
+}
+
+// ListCategories 返回全部分类及文章数
+func (s *TagService) ListCategories(ctx context.Context) ([]repository.CategoryCount, error) {
+	return s.categories.ListWithCounts(ctx)
+}
+
+// CreateCategory 创建分类，名称已存在时返回 ErrCategoryExists
+func (s *TagService) CreateCategory(ctx context.Context, in CreateCategoryInput) (*models.Category, error) {
+	if _, err := s.categories.FindByName(ctx, in.Name); err == nil {
+		return nil, ErrCategoryExists
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	category := &models.Category{Name: in.Name, Description: in.Description}
+	if err := s.categories.Create(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// normalizeTags 去掉首尾空白、转为小写并去重，保持原有顺序。
+// 任一标签为空或超过 MaxTagLength 个字符时返回 ErrInvalidTag。
+func normalizeTags(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || utf8.RuneCountInString(name) > MaxTagLength {
+			return nil, ErrInvalidTag
+		}
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
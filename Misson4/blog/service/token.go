@@ -0,0 +1,197 @@
+package service
+
+import (
+	"blog/models"
+	"blog/repository"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AccessTokenSigner 为用户签发访问令牌，返回令牌、jti 和过期时间
+type AccessTokenSigner func(user *models.User) (token, jti string, expiresAt time.Time, err error)
+
+// TokenPair 一次登录或刷新得到的访问令牌和刷新令牌
+type TokenPair struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+// TokenService 签发、轮换和注销令牌
+type TokenService struct {
+	tokens     repository.TokenRepository
+	users      repository.UserRepository
+	sign       AccessTokenSigner
+	refreshTTL time.Duration
+}
+
+// NewTokenService 创建 TokenService
+func NewTokenService(tokens repository.TokenRepository, users repository.UserRepository, sign AccessTokenSigner, refreshTTL time.Duration) *TokenService {
+	return &TokenService{tokens: tokens, users: users, sign: sign, refreshTTL: refreshTTL}
+}
+
+// Issue 登录成功后签发令牌，刷新令牌属于一个新的 family
+func (s *TokenService) Issue(ctx context.Context, user *models.User) (*TokenPair, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, user, familyID)
+}
+
+// Refresh 用刷新令牌换取新的令牌对，旧刷新令牌随即作废。
+// 已作废的刷新令牌被再次使用时，作废整个 family 并返回 ErrTokenReused。
+func (s *TokenService) Refresh(ctx context.Context, raw string) (*TokenPair, *models.User, error) {
+	stored, err := s.tokens.FindRefreshTokenByHash(ctx, hashToken(raw))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if stored.RevokedAt != nil {
+		if err := s.tokens.RevokeFamily(ctx, stored.FamilyID, now); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrTokenReused
+	}
+	if now.After(stored.ExpiresAt) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	// 条件更新保证并发刷新时只有一个请求成功，另一个按重放处理
+	ok, err := s.tokens.RevokeRefreshToken(ctx, stored.ID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		if err := s.tokens.RevokeFamily(ctx, stored.FamilyID, now); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrTokenReused
+	}
+
+	user, err := s.users.FindByID(ctx, stored.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pair, err := s.issue(ctx, user, stored.FamilyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pair, user, nil
+}
+
+// Logout 注销当前访问令牌（jti 加入黑名单直到过期），
+// 并在提供了刷新令牌时作废其所在的 family。不属于 userID 的刷新令牌会被忽略。
+func (s *TokenService) Logout(ctx context.Context, userID uint, refreshToken, jti string, accessExpiresAt time.Time) error {
+	if jti != "" {
+		if err := s.tokens.RevokeAccessToken(ctx, jti, accessExpiresAt); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+	stored, err := s.tokens.FindRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if stored.UserID != userID {
+		return nil
+	}
+	return s.tokens.RevokeFamily(ctx, stored.FamilyID, time.Now())
+}
+
+// LogoutAll 注销当前访问令牌并作废用户所有的刷新令牌，用于修改密码和注销账号。
+// 修改密码时其他设备上已签发的访问令牌在过期前仍然有效；注销账号后认证中间件会拒绝该用户的所有令牌。
+func (s *TokenService) LogoutAll(ctx context.Context, userID uint, jti string, accessExpiresAt time.Time) error {
+	if jti != "" {
+		if err := s.tokens.RevokeAccessToken(ctx, jti, accessExpiresAt); err != nil {
+			return err
+		}
+	}
+	return s.tokens.RevokeUserRefreshTokens(ctx, userID, time.Now())
+}
+
+// IsRevoked 判断访问令牌是否已被注销，供认证中间件使用
+func (s *TokenService) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return s.tokens.IsAccessTokenRevoked(ctx, jti)
+}
+
+// CurrentRole 返回用户当前的角色，供认证中间件使用。用户已注销时 exists 为 false
+func (s *TokenService) CurrentRole(ctx context.Context, userID uint) (models.Role, bool, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return user.Role, true, nil
+}
+
+// PurgeExpired 清理已过期的刷新令牌和黑名单记录
+func (s *TokenService) PurgeExpired(ctx context.Context) error {
+	return s.tokens.DeleteExpired(ctx, time.Now())
+}
+
+func (s *TokenService) issue(ctx context.Context, user *models.User, familyID string) (*TokenPair, error) {
+	access, _, accessExp, err := s.sign(user)
+	if err != nil {
+		return nil, fmt.Errorf("sign access token: %w", err)
+	}
+
+	raw, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	refresh := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+	if err := s.tokens.CreateRefreshToken(ctx, refresh); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:           access,
+		AccessTokenExpiresAt:  accessExp,
+		RefreshToken:          raw,
+		RefreshTokenExpiresAt: refresh.ExpiresAt,
+	}, nil
+}
+
+// randomToken 生成 n 字节的随机数并以 URL 安全的 base64 编码
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken 返回令牌的 SHA-256 十六进制摘要，数据库中只保存摘要
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"blog/models"
+	"blog/repository"
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RegisterInput 注册参数
+type RegisterInput struct {
+	Username string
+	Password string
+	Email    string
+}
+
+// UserService 用户注册与登录
+type UserService struct {
+	users repository.UserRepository
+}
+
+// NewUserService 创建 UserService
+func NewUserService(users repository.UserRepository) *UserService {
+	return &UserService{users: users}
+}
+
+// Register 注册新用户，用户名或邮箱已存在时返回 ErrUsernameTaken / ErrEmailTaken
+func (s *UserService) Register(ctx context.Context, in RegisterInput) (*models.User, error) {
+	if _, err := s.users.FindByUsername(ctx, in.Username); err == nil {
+		return nil, ErrUsernameTaken
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	if _, err := s.users.FindByEmail(ctx, in.Email); err == nil {
+		return nil, ErrEmailTaken
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	// 加密密码
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(in.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("hash password: %w", err)
+	}
+
+	user := &models.User{
+		Username: in.Username,
+		Password: string(hashedPassword),
+		Email:    in.Email,
+		Role:     models.RoleAuthor,
+	}
+	if err := s.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Authenticate 校验用户名和密码，失败时统一返回 ErrInvalidCredentials
+func (s *UserService) Authenticate(ctx context.Context, username, password string) (*models.User, error) {
+	user, err := s.users.FindByUsername(ctx, username)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// List 分页返回用户，按 ID 升序
+func (s *UserService) List(ctx context.Context, page, pageSize int) ([]models.User, int64, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	return s.users.List(ctx, (page-1)*pageSize, pageSize)
+}
+
+// SetRole 修改用户角色。管理员不能修改自己的角色，避免系统失去最后一个管理员。
+func (s *UserService) SetRole(ctx context.Context, actor Actor, userID uint, role models.Role) (*models.User, error) {
+	if !role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	if actor.ID == userID {
+		return nil, ErrOwnRole
+	}
+
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.users.UpdateRole(ctx, user.ID, role); err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return user, nil
+}
+
+// SetRoleByUsername 按用户名修改角色，供命令行初始化管理员使用
+func (s *UserService) SetRoleByUsername(ctx context.Context, username string, role models.Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+
+	user, err := s.users.FindByUsername(ctx, username)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.users.UpdateRole(ctx, user.ID, role)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"blog/repository"
+	"context"
+	"sync"
+)
+
+// ViewCounter 在内存中累计文章浏览量，由 Flush 定期批量写入数据库，避免每次浏览都写一次数据库。
+// 正常退出时会先执行一次 Flush，只有进程崩溃或被强制终止时才会丢失未写入的浏览量。
+type ViewCounter struct {
+	posts repository.PostRepository
+
+	mu      sync.Mutex
+	pending map[uint]int64
+}
+
+// NewViewCounter 创建 ViewCounter
+func NewViewCounter(posts repository.PostRepository) *ViewCounter {
+	return &ViewCounter{posts: posts, pending: make(map[uint]int64)}
+}
+
+// Hit 记录一次浏览
+func (v *ViewCounter) Hit(postID uint) {
+	v.mu.Lock()
+	v.pending[postID]++
+	v.mu.Unlock()
+}
+
+// Pending 返回文章尚未写入数据库的浏览量
+func (v *ViewCounter) Pending(postID uint) int64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.pending[postID]
+}
+
+// Flush 把累计的浏览量写入数据库。写入失败时把浏览量放回，下次一起重试
+func (v *ViewCounter) Flush(ctx context.Context) error {
+	v.mu.Lock()
+	counts := v.pending
+	v.pending = make(map[uint]int64)
+	v.mu.Unlock()
+
+	if len(counts) == 0 {
+		return nil
+	}
+	if err := v.posts.AddViews(ctx, counts); err != nil {
+		v.mu.Lock()
+		for id, n := range counts {
+			v.pending[id] += n
+		}
+		v.mu.Unlock()
+		return err
+	}
+	return nil
+}
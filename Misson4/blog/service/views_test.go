@@ -0,0 +1,59 @@
+package service
+
+import (
+	"blog/repository"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// viewStore 只实现 AddViews 的文章仓储，记录每次写入的浏览量
+type viewStore struct {
+	repository.PostRepository
+	writes []map[uint]int64
+	err    error
+}
+
+func (s *viewStore) AddViews(ctx context.Context, counts map[uint]int64) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.writes = append(s.writes, counts)
+	return nil
+}
+
+func TestViewCounterFlush(t *testing.T) {
+	ctx := context.Background()
+	store := &viewStore{}
+	views := NewViewCounter(store)
+
+	// 没有浏览量时不写数据库
+	if err := views.Flush(ctx); err != nil || len(store.writes) != 0 {
+		t.Fatalf("empty Flush() = %v, %d writes", err, len(store.writes))
+	}
+
+	views.Hit(1)
+	views.Hit(1)
+	views.Hit(2)
+	if n := views.Pending(1); n != 2 {
+		t.Fatalf("Pending(1) = %d, want 2", n)
+	}
+
+	// 写入失败时浏览量保留到下一次
+	store.err = errors.New("db down")
+	if err := views.Flush(ctx); err == nil {
+		t.Fatal("Flush() succeeded with a failing store")
+	}
+	views.Hit(1)
+	store.err = nil
+	if err := views.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want := []map[uint]int64{{1: 3, 2: 1}}; !reflect.DeepEqual(store.writes, want) {
+		t.Errorf("writes = %v, want %v", store.writes, want)
+	}
+	if n := views.Pending(1); n != 0 {
+		t.Errorf("Pending(1) = %d after Flush, want 0", n)
+	}
+}
@@ -0,0 +1,87 @@
+// Package signedtoken 生成和校验邮件链接中使用的无状态令牌。
+// 令牌由 base64url 编码的载荷和 HMAC-SHA256 签名组成，包含用途、用户、过期时间
+// 和用户当前状态（如邮箱、密码哈希）的指纹：状态变化后令牌自动失效，不需要在数据库中保存。
+package signedtoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalid 令牌格式错误、签名不匹配、用途不符或已过期
+var ErrInvalid = errors.New("invalid or expired token")
+
+// Claims 令牌的内容
+type Claims struct {
+	Purpose   string `json:"p"`
+	UserID    uint   `json:"u"`
+	ExpiresAt int64  `json:"x"` // Unix 秒
+	// State 签发时用户状态的指纹，由 Fingerprint 生成
+	State string `json:"s"`
+}
+
+// Signer 用同一个密钥签发和校验令牌
+type Signer struct {
+	key []byte
+}
+
+// New 创建 Signer
+func New(key []byte) *Signer {
+	return &Signer{key: key}
+}
+
+// DeriveKey 从其他用途的密钥派生出专用于 purpose 的密钥，避免同一个密钥用于多种签名
+func DeriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// Fingerprint 返回状态值的指纹，用于 Claims.State
+func Fingerprint(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+// Sign 签发令牌
+func (s *Signer) Sign(claims Claims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + s.signature(encoded), nil
+}
+
+// Verify 校验令牌的签名、用途和过期时间，返回其内容。
+// 调用方还需要用 Fingerprint 比对 State 和用户当前的状态。
+func (s *Signer) Verify(token, purpose string, now time.Time) (*Claims, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(s.signature(encoded))) {
+		return nil, ErrInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalid
+	}
+	if claims.Purpose != purpose || now.Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalid
+	}
+	return &claims, nil
+}
+
+func (s *Signer) signature(encoded string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
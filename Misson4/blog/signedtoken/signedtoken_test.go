@@ -0,0 +1,73 @@
+package signedtoken
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	signer := New([]byte("secret"))
+	claims := Claims{Purpose: "reset", UserID: 7, ExpiresAt: now.Add(time.Hour).Unix(), State: Fingerprint("hash")}
+	token, err := signer.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, sig, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name    string
+		signer  *Signer
+		token   string
+		purpose string
+		now     time.Time
+		wantErr bool
+	}{
+		{"valid", signer, token, "reset", now, false},
+		{"wrong purpose", signer, token, "verify", now, true},
+		{"expired", signer, token, "reset", now.Add(time.Hour), true},
+		{"other key", New([]byte("other")), token, "reset", now, true},
+		{"tampered payload", signer, "x" + payload + "." + sig, "reset", now, true},
+		{"tampered signature", signer, payload + "." + sig[:len(sig)-1], "reset", now, true},
+		{"no separator", signer, payload, "reset", now, true},
+		{"empty", signer, "", "reset", now, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.signer.Verify(tt.token, tt.purpose, tt.now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalid) {
+					t.Fatalf("Verify() error = %v, want ErrInvalid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != claims {
+				t.Errorf("Verify() = %+v, want %+v", *got, claims)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	if Fingerprint("a") != Fingerprint("a") {
+		t.Error("Fingerprint is not deterministic")
+	}
+	if Fingerprint("a") == Fingerprint("b") {
+		t.Error("different states have the same fingerprint")
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	secret := []byte("secret")
+	if string(DeriveKey(secret, "mail")) == string(DeriveKey(secret, "other")) {
+		t.Error("different purposes derive the same key")
+	}
+	if string(DeriveKey(secret, "mail")) == string(secret) {
+		t.Error("derived key equals the secret")
+	}
+}
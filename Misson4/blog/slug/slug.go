@@ -0,0 +1,82 @@
+// Package slug 根据文章标题生成 URL 友好的短名称：只包含小写字母、数字和连字符，
+// 汉字转换为不带声调的拼音，带变音符号的拉丁字母去掉变音符号。
+package slug
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength slug 的最大长度，预留了冲突时追加的数字后缀
+const MaxLength = 80
+
+// Fallback 标题中没有任何可用字符时使用的 slug
+const Fallback = "post"
+
+var pinyinArgs = pinyin.NewArgs()
+
+// Make 生成标题对应的 slug，例如 "Go 语言入门" -> "go-yu-yan-ru-men"
+func Make(title string) string {
+	var b strings.Builder
+	sep := false // 下一个字符之前是否需要连字符
+	for _, r := range norm.NFD.String(title) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// NFD 分解出的变音符号，直接丢弃
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if sep && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			sep = false
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.Is(unicode.Han, r):
+			// 每个汉字的拼音单独成词
+			if py := pinyin.SinglePinyin(r, pinyinArgs); len(py) > 0 {
+				if b.Len() > 0 {
+					b.WriteByte('-')
+				}
+				b.WriteString(py[0])
+			}
+			sep = true
+		default:
+			sep = true
+		}
+	}
+
+	return truncate(b.String(), MaxLength)
+}
+
+// truncate 把 slug 截断到不超过 n 个字节，尽量在连字符处截断以保留完整的词
+func truncate(s string, n int) string {
+	if s == "" {
+		return Fallback
+	}
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	if i := strings.LastIndexByte(s, '-'); i > 0 {
+		s = s[:i]
+	}
+	return strings.Trim(s, "-")
+}
+
+// Unique 依次尝试 base、base-2、base-3……，返回第一个 taken 判定为未被占用的 slug
+func Unique(base string, taken func(slug string) (bool, error)) (string, error) {
+	candidate := base
+	for i := 2; ; i++ {
+		used, err := taken(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !used {
+			return candidate, nil
+		}
+		candidate = base + "-" + strconv.Itoa(i)
+	}
+}
@@ -0,0 +1,65 @@
+package slug
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{"ascii", "Hello World", "hello-world"},
+		{"punctuation", "  Go: tips & tricks!! ", "go-tips-tricks"},
+		{"digits", "Top 10 Go 1.21 features", "top-10-go-1-21-features"},
+		{"chinese", "Go 语言入门", "go-yu-yan-ru-men"},
+		{"diacritics", "Café Crème", "cafe-creme"},
+		{"no usable characters", "!!!", Fallback},
+		{"empty", "", Fallback},
+		{"emoji only", "🎉🎉", Fallback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Make(tt.title); got != tt.want {
+				t.Errorf("Make(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeTruncates(t *testing.T) {
+	got := Make(strings.Repeat("word ", 40))
+	if len(got) > MaxLength {
+		t.Fatalf("len(Make()) = %d, want at most %d", len(got), MaxLength)
+	}
+	if strings.HasSuffix(got, "-") || !strings.HasSuffix(got, "word") {
+		t.Errorf("Make() = %q, want it cut at a word boundary", got)
+	}
+}
+
+func TestUnique(t *testing.T) {
+	errStore := errors.New("store down")
+	tests := []struct {
+		name    string
+		taken   map[string]bool
+		err     error
+		want    string
+		wantErr error
+	}{
+		{"free", nil, nil, "hello", nil},
+		{"base taken", map[string]bool{"hello": true}, nil, "hello-2", nil},
+		{"several taken", map[string]bool{"hello": true, "hello-2": true, "hello-3": true}, nil, "hello-4", nil},
+		{"store error", nil, errStore, "", errStore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Unique("hello", func(s string) (bool, error) { return tt.taken[s], tt.err })
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Unique() = %q, %v; want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
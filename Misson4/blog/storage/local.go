@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Local 把文件保存在本地目录中
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocal 创建本地存储，dir 不存在时自动创建；baseURL 为文件的访问地址前缀
+func NewLocal(dir, baseURL string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Local{dir: dir, baseURL: baseURL}, nil
+}
+
+// Dir 文件保存的根目录
+func (l *Local) Dir() string {
+	return l.dir
+}
+
+// Put 先写入同目录下的临时文件再重命名，读取方不会看到写了一半的文件
+func (l *Local) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	path := filepath.Join(l.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Delete 删除 dir 下的文件，文件不存在时不报错
+func (l *Local) Delete(_ context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(l.dir, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// URL 返回 baseURL 加上 key 的访问地址
+func (l *Local) URL(key string) string {
+	return joinURL(l.baseURL, key)
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"blog/config"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3 把文件保存在 S3 兼容的对象存储中，文件需要通过公开读的 bucket 或 CDN 访问
+type S3 struct {
+	client  *minio.Client
+	bucket  string
+	baseURL string
+}
+
+// NewS3 连接对象存储，bucket 不存在时自动创建
+func NewS3(ctx context.Context, cfg config.S3StorageConfig) (*S3, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: connect to %s: %w", cfg.Endpoint, err)
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("storage: check bucket %s: %w", cfg.Bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, fmt.Errorf("storage: create bucket %s: %w", cfg.Bucket, err)
+		}
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		scheme := "http"
+		if cfg.UseSSL {
+			scheme = "https"
+		}
+		baseURL = scheme + "://" + cfg.Endpoint + "/" + cfg.Bucket
+	}
+	return &S3{client: client, bucket: cfg.Bucket, baseURL: baseURL}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+// Delete 删除对象；S3 删除不存在的对象本身就不会报错
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3) URL(key string) string {
+	return joinURL(s.baseURL, key)
+}
@@ -0,0 +1,50 @@
+// Package storage 保存用户上传的文件。本地磁盘和 S3 兼容对象存储（AWS S3、MinIO 等）
+// 实现同一个接口，通过配置切换。
+package storage
+
+import (
+	"blog/config"
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrInvalidKey key 为空、以 / 开头或包含 . / .. 路径段
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// Storage 按 key 保存文件。key 是以 / 分隔的相对路径，由调用方生成
+type Storage interface {
+	// Put 保存文件，size 为 r 的总字节数，已存在的同名文件会被覆盖
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Delete 删除文件，文件不存在时不报错
+	Delete(ctx context.Context, key string) error
+	// URL 返回文件的公开访问地址
+	URL(key string) string
+}
+
+// New 根据配置创建存储后端
+func New(ctx context.Context, cfg config.StorageConfig) (Storage, error) {
+	if cfg.Driver == config.StorageS3 {
+		return NewS3(ctx, cfg.S3)
+	}
+	return NewLocal(cfg.Local.Dir, cfg.Local.BaseURL)
+}
+
+// checkKey 拒绝可能逃出存储目录的 key
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." || strings.Contains(part, `\`) {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// joinURL 把 key 拼接到地址前缀之后
+func joinURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + key
+}
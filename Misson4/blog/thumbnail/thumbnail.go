@@ -0,0 +1,96 @@
+// Package thumbnail 为上传的图片生成缩略图，支持 JPEG、PNG、GIF（取第一帧）和 WebP。
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // 注册 WebP 解码器
+)
+
+// MaxSize 缩略图最长边的像素数
+const MaxSize = 320
+
+// maxPixels 原图像素数上限，避免解码尺寸巨大的图片耗尽内存
+const maxPixels = 40_000_000
+
+// 错误
+var (
+	ErrUnsupported = errors.New("thumbnail: unsupported or corrupt image")
+	ErrTooLarge    = errors.New("thumbnail: image dimensions too large")
+)
+
+// Thumbnail 生成的缩略图，Width、Height 为原图尺寸
+type Thumbnail struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Make 解码图片并等比缩小到最长边不超过 MaxSize，比 MaxSize 小的图片保持原尺寸。
+// JPEG 输出为 JPEG，其他格式输出为 PNG 以保留透明度。
+func Make(r io.Reader) (*Thumbnail, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrUnsupported
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	var src image.Image
+	if format == "gif" {
+		// image.Decode 对 GIF 只返回第一帧，这里显式调用以免解码全部帧
+		src, err = gif.Decode(bytes.NewReader(data))
+	} else {
+		src, _, err = image.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+
+	w, h := fit(cfg.Width, cfg.Height, MaxSize)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	thumb := &Thumbnail{Width: cfg.Width, Height: cfg.Height}
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		thumb.ContentType = "image/jpeg"
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	} else {
+		thumb.ContentType = "image/png"
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, err
+	}
+	thumb.Data = buf.Bytes()
+	return thumb, nil
+}
+
+// fit 计算把 w×h 等比缩小到最长边不超过 limit 后的尺寸
+func fit(w, h, limit int) (int, int) {
+	if w <= limit && h <= limit {
+		return w, h
+	}
+	if w >= h {
+		return limit, max(1, h*limit/w)
+	}
+	return max(1, w*limit/h), limit
+}
@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// spanKey 查询 span 在 gorm.Statement 中的键
+const spanKey = "tracing:span"
+
+// GormPlugin 为每次 GORM 操作记录一个 span，父 span 取自查询的 context（db.WithContext）。
+// span 中记录带占位符的 SQL，不包含参数值
+type GormPlugin struct{}
+
+// Name 插件名称
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+// register 注册回调的函数，对应 gorm 回调的 Register 方法
+type register func(name string, fn func(*gorm.DB)) error
+
+// Initialize 在每类操作的全部回调之前开始 span，之后结束 span
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		op            string
+		before, after register
+	}{
+		{"create", cb.Create().Before("*").Register, cb.Create().After("*").Register},
+		{"query", cb.Query().Before("*").Register, cb.Query().After("*").Register},
+		{"update", cb.Update().Before("*").Register, cb.Update().After("*").Register},
+		{"delete", cb.Delete().Before("*").Register, cb.Delete().After("*").Register},
+		{"row", cb.Row().Before("*").Register, cb.Row().After("*").Register},
+		{"raw", cb.Raw().Before("*").Register, cb.Raw().After("*").Register},
+	}
+	for _, h := range hooks {
+		if err := h.before("tracing:before_"+h.op, startSpan("gorm."+h.op)); err != nil {
+			return err
+		}
+		if err := h.after("tracing:after_"+h.op, endSpan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startSpan 返回开始 span 的回调，span 保存在本次查询的 Statement 中
+func startSpan(name string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		_, span := Tracer().Start(db.Statement.Context, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemKey.String(db.Dialector.Name())),
+		)
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+// endSpan 记录 SQL、表名、影响行数和错误后结束 span。
+// 业务代码用 record not found 判断记录是否存在，不作为错误记录
+func endSpan(db *gorm.DB) {
+	v, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := v.(trace.Span)
+	defer span.End()
+
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+	}
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
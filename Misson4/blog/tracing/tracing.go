@@ -0,0 +1,85 @@
+// Package tracing 配置 OpenTelemetry 链路追踪。HTTP 请求的 span 由 middleware.Tracing 创建，
+// 数据库查询的 span 由 GormPlugin 创建，查询 span 是所属请求 span 的子 span。
+// 未启用时全局 TracerProvider 保持默认的空实现，创建 span 没有开销。
+package tracing
+
+import (
+	"blog/config"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName 本服务创建 span 时使用的 tracer 名称
+const InstrumentationName = "blog"
+
+// Tracer 返回本服务使用的 tracer
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// Setup 按配置创建 TracerProvider 并设为全局默认，同时启用 W3C traceparent 和 baggage 的传播。
+// 返回的函数在退出前调用，导出缓冲中尚未发送的 span
+func Setup(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("tracing: create resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// 上游已经决定是否采样时沿用上游的决定，否则按比例采样
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	// 导出失败（如 Collector 不可用）只记录警告，不影响请求处理
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Warn("tracing export failed", "error", err)
+	}))
+	return provider.Shutdown, nil
+}
+
+// newExporter 根据配置创建 span 导出器
+func newExporter(ctx context.Context, cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case config.TracingOTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("tracing: create otlp exporter: %w", err)
+		}
+		return exporter, nil
+	case config.TracingStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("tracing: create stdout exporter: %w", err)
+		}
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("tracing: unsupported exporter %q", cfg.Exporter)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"blog/database"
+	"blog/models"
+	"blog/repository"
+	"blog/service"
+	"context"
+	"fmt"
+)
+
+// runUser 处理 user 子命令
+func runUser(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("user: missing subcommand (set-role)")
+	}
+
+	switch args[0] {
+	case "set-role":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: user set-role <username> <role>")
+		}
+		users := service.NewUserService(repository.NewUserRepository(database.DB))
+		if err := users.SetRoleByUsername(context.Background(), args[1], models.Role(args[2])); err != nil {
+			return fmt.Errorf("user set-role: %w", err)
+		}
+		fmt.Printf("User %s is now %s\n", args[1], args[2])
+		return nil
+
+	default:
+		return fmt.Errorf("user: unknown subcommand %q (want set-role)", args[0])
+	}
+}